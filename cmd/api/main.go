@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/dr15/internship-hub-api/config"
 	"github.com/dr15/internship-hub-api/database"
@@ -10,7 +13,9 @@ import (
 	"github.com/dr15/internship-hub-api/internal/middleware"
 	"github.com/dr15/internship-hub-api/internal/models"
 	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/dr15/internship-hub-api/internal/scheduler"
 	"github.com/dr15/internship-hub-api/internal/services"
+	"github.com/dr15/internship-hub-api/internal/storage"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -37,15 +42,85 @@ func main() {
 
 	// Initialize Repositories
 	userRepo := repository.NewUserRepository(database.DB)
+	tokenRepo := repository.NewRefreshTokenRepository(database.DB)
+	sessionRepo := repository.NewSessionRepository(database.DB)
+	twoFactorRepo := repository.NewTwoFactorRepository(database.DB)
+	oidcRepo := repository.NewOIDCRepository(database.DB)
+	roleRepo := repository.NewRoleRepository(database.DB)
 	vacancyRepo := repository.NewVacancyRepository(database.DB)
 	appRepo := repository.NewApplicationRepository(database.DB)
 	attendanceRepo := repository.NewAttendanceRepository(database.DB)
 	unitKerjaRepo := repository.NewUnitKerjaRepository(database.DB)
 	resultRepo := repository.NewInternshipResultRepository(database.DB)
-	pdfService := services.NewPDFService("uploads")
+	jobRunRepo := repository.NewJobRunRepository(database.DB)
+	interviewRepo := repository.NewInterviewRepository(database.DB)
+	reviewRepo := repository.NewApplicationReviewRepository(database.DB)
+	mentorshipRepo := repository.NewMentorshipRepository(database.DB)
+	campusSupervisorRepo := repository.NewCampusSupervisorRepository(database.DB)
+	fileRepo := repository.NewStoredFileRepository(database.DB)
+	store, err := storage.FromConfig(config.AppConfig.StorageDriver, config.AppConfig)
+	if err != nil {
+		log.Fatalf("Failed to open file storage: %v", err)
+	}
+	pdfService := services.NewPDFService(store)
+	authz := services.NewAuthorizationService(roleRepo)
+	notifier := services.NewNotificationService(userRepo, authz)
+	waitlist := services.NewWaitlistService(appRepo, notifier)
+	throttle := services.NewAuthThrottleService(repository.NewAuthThrottleRepository(database.DB))
+	oidc := services.NewOIDCService(config.AppConfig)
 
 	// Initialize Handlers
-	h := handlers.NewHandler(userRepo, vacancyRepo, appRepo, attendanceRepo, unitKerjaRepo, resultRepo, pdfService)
+	h := handlers.NewHandler(userRepo, tokenRepo, sessionRepo, twoFactorRepo, oidcRepo, roleRepo, vacancyRepo, appRepo, attendanceRepo, unitKerjaRepo, resultRepo, jobRunRepo, interviewRepo, reviewRepo, mentorshipRepo, campusSupervisorRepo, fileRepo, store, pdfService, notifier, waitlist, throttle, oidc, authz)
+
+	// Background Jobs
+	if config.AppConfig.SchedulerEnabled == "true" {
+		sched := scheduler.New(database.DB, jobRunRepo)
+		jobs := []struct {
+			name string
+			cron string
+			run  scheduler.JobFunc
+		}{
+			{"close_expired_vacancies", config.AppConfig.CronCloseVacancies, vacancyRepo.CloseExpired},
+			{"finish_ended_internships", config.AppConfig.CronFinishInternships, appRepo.FinishEnded},
+			{"mark_absent_interns", config.AppConfig.CronMarkAbsent, func() (int64, error) {
+				return attendanceRepo.MarkAbsent(time.Now().AddDate(0, 0, -1))
+			}},
+			{"expire_offers", config.AppConfig.CronExpireOffers, func() (int64, error) {
+				expired, err := appRepo.ExpireOffers()
+				if err != nil {
+					return 0, err
+				}
+				for _, app := range expired {
+					notifier.OfferReleased(app, "batas waktu penawaran terlewati")
+				}
+				// Hand the released seats, and any other free ones, to the waitlists
+				promoted, err := waitlist.PromoteAll()
+				return int64(len(expired)) + promoted, err
+			}},
+			{"purge_expired_tokens", config.AppConfig.CronPurgeTokens, func() (int64, error) {
+				tokens, err := tokenRepo.DeleteExpired()
+				if err != nil {
+					return tokens, err
+				}
+				sessions, err := sessionRepo.DeleteExpired()
+				if err != nil {
+					return tokens + sessions, err
+				}
+				throttles, err := throttle.PurgeStale()
+				if err != nil {
+					return tokens + sessions + throttles, err
+				}
+				attempts, err := oidcRepo.DeleteExpired()
+				return tokens + sessions + throttles + attempts, err
+			}},
+		}
+		for _, job := range jobs {
+			if err := sched.Register(job.name, job.cron, job.run); err != nil {
+				log.Fatalf("Invalid schedule for job %s: %v", job.name, err)
+			}
+		}
+		go sched.Start(context.Background())
+	}
 
 	port := config.AppConfig.ServerPort
 	if port == "" {
@@ -57,9 +132,6 @@ func main() {
 	// Swagger route
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Static files
-	r.Static("/uploads", "./uploads")
-
 	// CORS Middleware
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
@@ -80,70 +152,162 @@ func main() {
 	{
 		api.POST("/register", h.Register)
 		api.POST("/login", h.Login)
+		api.POST("/login/2fa", h.LoginTwoFactor)
+		api.POST("/login/2fa/setup", h.SetupTwoFactorEnrollment)
+		api.POST("/login/2fa/enroll", h.EnrollTwoFactor)
+		api.POST("/token/refresh", h.RefreshToken)
 		api.POST("/forgot-password", h.ForgotPassword)
 		api.POST("/reset-password", h.ResetPassword)
+		api.POST("/verify-email", h.VerifyEmail)
+		api.GET("/auth/oidc/login", h.OIDCLogin)
+		api.GET("/auth/oidc/callback", h.OIDCCallback)
+		api.POST("/auth/oidc/exchange", h.OIDCExchange)
 		api.GET("/units", h.GetUnits)
 		api.GET("/vacancies", h.GetVacancies)
-		api.GET("/vacancies/:id", h.GetVacancy)
+		api.GET("/vacancies/:id", middleware.OptionalAuthMiddleware(userRepo, sessionRepo), h.GetVacancy)
+		api.GET("/files/download", h.DownloadFile)
+		api.GET("/supervisor-invitations/:token", h.GetSupervisorInvitation)
+		api.POST("/supervisor-invitations/accept", h.AcceptSupervisorInvitation)
 	}
 
 	// Protected Routes
 	auth := api.Group("")
-	auth.Use(middleware.AuthMiddleware())
+	auth.Use(middleware.AuthMiddleware(userRepo, sessionRepo))
+	can := func(permission models.Permission) gin.HandlerFunc {
+		return middleware.PermissionMiddleware(authz, permission)
+	}
 	{
 		auth.GET("/me", h.Me)
+		auth.POST("/logout", h.Logout)
+		auth.GET("/me/sessions", h.GetMySessions)
+		auth.DELETE("/me/sessions", h.RevokeMyOtherSessions)
+		auth.DELETE("/me/sessions/:id", h.RevokeMySession)
+		auth.GET("/me/2fa", h.GetTwoFactorStatus)
+		auth.POST("/me/2fa/setup", h.SetupTwoFactor)
+		auth.POST("/me/2fa/enable", h.EnableTwoFactor)
+		auth.POST("/me/2fa/disable", h.DisableTwoFactor)
+		auth.POST("/me/2fa/recovery-codes", h.RegenerateRecoveryCodes)
 		auth.PUT("/me", h.UpdateProfile)
 		auth.POST("/change-password", h.ChangePassword)
+		auth.POST("/verify-email/resend", h.ResendVerification)
+		auth.GET("/applications/:id/history", h.GetApplicationHistory)
+		auth.GET("/applications/:id/files/:file", h.GetApplicationFileLink)
+		auth.GET("/applications/:id/answers/:questionId/file", h.GetAnswerFileLink)
+		// Students and unit admins invite campus supervisors; the handlers check which
+		auth.POST("/applications/:id/supervisors", h.InviteCampusSupervisor)
+		auth.GET("/applications/:id/supervisors", h.GetCampusSupervisors)
+		auth.DELETE("/applications/:id/supervisors/:supervisorId", h.RevokeCampusSupervisor)
 
 		// Applicant Routes
-		applicant := auth.Group("")
-		applicant.Use(middleware.RoleMiddleware(models.UserRoleApplicant))
-		{
-			applicant.POST("/applications", h.SubmitApplication)
-			applicant.GET("/applications/my", h.GetUserApplications)
-			// Attendance for intern
-			applicant.POST("/attendance/check-in", h.CheckIn)
-			applicant.POST("/attendance/check-out", h.CheckOut)
-			applicant.GET("/attendance/my", h.GetMyAttendance)
-			// Internship Result
-			applicant.POST("/internship/report", h.SubmitReport)
-			applicant.GET("/internship/result/my", h.GetMyInternshipResult)
-		}
+		auth.POST("/applications", can(models.PermissionApplicationApply), h.SubmitApplication)
+		auth.GET("/applications/my", can(models.PermissionApplicationApply), h.GetUserApplications)
+		auth.POST("/applications/:id/withdraw", can(models.PermissionApplicationApply), h.WithdrawApplication)
+		auth.PUT("/applications/:id/documents/:type", can(models.PermissionApplicationApply), h.ReplaceApplicationDocument)
+		auth.POST("/applications/:id/offer/accept", can(models.PermissionApplicationApply), h.AcceptOffer)
+		auth.POST("/applications/:id/offer/decline", can(models.PermissionApplicationApply), h.DeclineOffer)
+		auth.GET("/applications/:id/interview", can(models.PermissionInterviewBook), h.GetMyInterview)
+		auth.GET("/applications/:id/interview/slots", can(models.PermissionInterviewBook), h.GetAvailableInterviewSlots)
+		auth.POST("/applications/:id/interview/book", can(models.PermissionInterviewBook), h.BookInterviewSlot)
+		auth.GET("/applications/:id/interview/ics", can(models.PermissionInterviewBook), h.DownloadInterviewInvite)
+		// Attendance for intern
+		auth.POST("/attendance/check-in", can(models.PermissionAttendanceRecord), h.CheckIn)
+		auth.POST("/attendance/check-out", can(models.PermissionAttendanceRecord), h.CheckOut)
+		auth.GET("/attendance/my", can(models.PermissionAttendanceRecord), h.GetMyAttendance)
+		auth.POST("/logbook", can(models.PermissionAttendanceRecord), h.SaveLogbookEntry)
+		auth.GET("/logbook/my", can(models.PermissionAttendanceRecord), h.GetMyLogbook)
+		auth.POST("/leave-requests", can(models.PermissionAttendanceRecord), h.CreateLeaveRequest)
+		auth.GET("/leave-requests/my", can(models.PermissionAttendanceRecord), h.GetMyLeaveRequests)
+		auth.POST("/leave-requests/:id/cancel", can(models.PermissionAttendanceRecord), h.CancelLeaveRequest)
+		// Internship Result
+		auth.POST("/internship/report", can(models.PermissionInternshipReport), h.SubmitReport)
+		auth.GET("/internship/result/my", can(models.PermissionInternshipReport), h.GetMyInternshipResult)
 
-		// Administrative Routes (Both Unit and Central Admins)
-		admin := auth.Group("")
-		admin.Use(middleware.RoleMiddleware(models.UserRoleUnit, models.UserRoleCentral))
-		{
-			admin.POST("/vacancies", h.CreateVacancy)
-			admin.GET("/vacancies/admin", h.GetAllVacanciesAdmin)
-			admin.GET("/vacancies/:id/applications", h.GetVacancyApplications)
-			admin.PATCH("/applications/:id", h.ReviewApplication)
-			// Attendance recap for admin
-			admin.GET("/attendance/recap", h.GetAttendanceRecap)
-			admin.GET("/attendance/recap/:userId", h.GetIndividualRecap)
-			admin.GET("/attendance/export", h.ExportAttendance)
-			// Internship Evaluation
-			admin.GET("/internship/results", h.GetInternshipResultsForAdmin)
-			admin.POST("/internship/results/:id/review", h.ReviewInternship)
-		}
+		// Administrative Routes. Handlers acting on one unit's data also check
+		// that the permission covers that unit.
+		auth.POST("/vacancies", can(models.PermissionVacancyManage), h.CreateVacancy)
+		auth.POST("/vacancies/drafts", can(models.PermissionVacancyManage), h.CreateVacancyDraft)
+		auth.POST("/vacancies/:id/submit", can(models.PermissionVacancyManage), h.SubmitVacancy)
+		auth.PUT("/vacancies/:id", can(models.PermissionVacancyManage), h.UpdateVacancy)
+		auth.PATCH("/vacancies/:id/close", can(models.PermissionVacancyManage), h.CloseVacancy)
+		auth.PATCH("/vacancies/:id/archive", can(models.PermissionVacancyManage), h.ArchiveVacancy)
+		auth.GET("/vacancies/:id/revisions", can(models.PermissionVacancyManage), h.GetVacancyRevisions)
+		auth.PUT("/vacancies/:id/questions", can(models.PermissionVacancyManage), h.UpdateVacancyQuestions)
+		auth.GET("/vacancies/admin", can(models.PermissionVacancyManage), h.GetAllVacanciesAdmin)
+		auth.GET("/vacancies/:id/applications", can(models.PermissionApplicationView), h.GetVacancyApplications)
+		auth.GET("/vacancies/:id/applications/export", can(models.PermissionApplicationView), h.ExportVacancyApplications)
+		auth.PATCH("/applications/:id", can(models.PermissionApplicationReview), h.ReviewApplication)
+		auth.POST("/applications/bulk-review", can(models.PermissionApplicationReview), h.BulkReviewApplications)
+		auth.GET("/vacancies/:id/waitlist", can(models.PermissionApplicationView), h.GetWaitlist)
+		auth.PUT("/vacancies/:id/waitlist", can(models.PermissionApplicationReview), h.ReorderWaitlist)
+		auth.GET("/applications/:id/reviews", can(models.PermissionApplicationView), h.GetApplicationReviews)
+		auth.PUT("/applications/:id/rating", can(models.PermissionApplicationReview), h.RateApplication)
+		auth.DELETE("/applications/:id/rating", can(models.PermissionApplicationReview), h.DeleteApplicationRating)
+		auth.POST("/applications/:id/comments", can(models.PermissionApplicationReview), h.AddApplicationComment)
+		auth.DELETE("/application-comments/:id", can(models.PermissionApplicationReview), h.DeleteApplicationComment)
+		auth.PUT("/applications/:id/mentor", can(models.PermissionApplicationReview), h.AssignMentor)
+		auth.GET("/mentors", can(models.PermissionApplicationReview), h.GetMentors)
+		// Interviews
+		auth.POST("/vacancies/:id/interview-slots", can(models.PermissionInterviewManage), h.CreateInterviewSlot)
+		auth.GET("/vacancies/:id/interview-slots", can(models.PermissionInterviewManage), h.GetInterviewSlots)
+		auth.DELETE("/interview-slots/:id", can(models.PermissionInterviewManage), h.DeleteInterviewSlot)
+		auth.POST("/vacancies/:id/interviews/invite", can(models.PermissionInterviewManage), h.InviteToInterview)
+		auth.PATCH("/applications/:id/interview", can(models.PermissionInterviewManage), h.RecordInterviewOutcome)
+		// Attendance recap for admin
+		auth.GET("/attendance/recap", can(models.PermissionAttendanceView), h.GetAttendanceRecap)
+		auth.GET("/attendance/recap/:userId", can(models.PermissionAttendanceView), h.GetIndividualRecap)
+		auth.GET("/attendance/export", can(models.PermissionAttendanceExport), h.ExportAttendance)
+		// Mentors supervise only the interns assigned to them
+		auth.GET("/mentees", can(models.PermissionMenteeSupervise), h.GetMentees)
+		auth.GET("/mentees/:id/attendance", can(models.PermissionMenteeSupervise), h.GetMenteeAttendance)
+		auth.GET("/mentees/:id/logbook", can(models.PermissionMenteeSupervise), h.GetMenteeLogbook)
+		auth.GET("/mentees/:id/leave-requests", can(models.PermissionMenteeSupervise), h.GetMenteeLeaveRequests)
+		auth.PUT("/mentees/:id/evaluation", can(models.PermissionMenteeSupervise), h.SubmitMentorEvaluation)
+		auth.PATCH("/logbook/:id", can(models.PermissionMenteeSupervise), h.ReviewLogbookEntry)
+		auth.PATCH("/leave-requests/:id", can(models.PermissionMenteeSupervise), h.ReviewLeaveRequest)
+		// Campus supervisors only see the internships they were invited to
+		auth.GET("/supervised", can(models.PermissionInternshipMonitor), h.GetSupervisedInternships)
+		auth.GET("/supervised/:id", can(models.PermissionInternshipMonitor), h.GetSupervisedInternship)
+		auth.GET("/supervised/:id/attendance", can(models.PermissionInternshipMonitor), h.GetSupervisedAttendance)
+		auth.GET("/supervised/:id/logbook", can(models.PermissionInternshipMonitor), h.GetSupervisedLogbook)
+		auth.GET("/supervised/:id/result", can(models.PermissionInternshipMonitor), h.GetSupervisedResult)
+		auth.GET("/supervised/:id/completion-letter", can(models.PermissionInternshipMonitor), h.GetSupervisedCompletionLetter)
+		// Internship Evaluation
+		auth.GET("/internship/results", can(models.PermissionInternshipEvaluate), h.GetInternshipResultsForAdmin)
+		auth.POST("/internship/results/:id/review", can(models.PermissionInternshipEvaluate), h.ReviewInternship)
 
-		// Central Admin Only Routes
-		central := auth.Group("")
-		central.Use(middleware.RoleMiddleware(models.UserRoleCentral))
-		{
-			central.PATCH("/vacancies/:id/approve", h.ApproveVacancy)
-
-			// User Management
-			central.GET("/users", h.GetUsers)
-			central.POST("/users", h.CreateUser)
-			central.PUT("/users/:id", h.UpdateUser)
-			central.DELETE("/users/:id", h.DeleteUser)
-
-			// Unit Kerja Management
-			central.POST("/units", h.CreateUnit)
-			central.PUT("/units/:id", h.UpdateUnit)
-			central.DELETE("/units/:id", h.DeleteUnit)
-		}
+		auth.GET("/vacancies/approval-queue", can(models.PermissionVacancyApprove), h.GetApprovalQueue)
+		auth.PATCH("/vacancies/:id/approve", can(models.PermissionVacancyApprove), h.ApproveVacancy)
+
+		// User Management
+		auth.GET("/users", can(models.PermissionUserManage), h.GetUsers)
+		auth.POST("/users", can(models.PermissionUserManage), h.CreateUser)
+		auth.PUT("/users/:id", can(models.PermissionUserManage), h.UpdateUser)
+		auth.DELETE("/users/:id", can(models.PermissionUserManage), h.DeleteUser)
+		auth.GET("/users/:id/sessions", can(models.PermissionUserManage), h.GetUserSessions)
+		auth.DELETE("/users/:id/sessions", can(models.PermissionUserManage), h.ForceLogoutUser)
+		auth.POST("/users/:id/unlock", can(models.PermissionUserManage), h.UnlockUser)
+		auth.POST("/users/:id/2fa/reset", can(models.PermissionUserManage), h.ResetUserTwoFactor)
+
+		// Roles and Permissions
+		auth.GET("/permissions", can(models.PermissionRoleManage), h.GetPermissions)
+		auth.GET("/roles", can(models.PermissionRoleManage), h.GetRoles)
+		auth.POST("/roles", can(models.PermissionRoleManage), h.CreateRole)
+		auth.PUT("/roles/:id", can(models.PermissionRoleManage), h.UpdateRole)
+		auth.DELETE("/roles/:id", can(models.PermissionRoleManage), h.DeleteRole)
+
+		// Single Sign-On Role Mappings
+		auth.GET("/oidc/role-mappings", can(models.PermissionRoleManage), h.GetOIDCRoleMappings)
+		auth.POST("/oidc/role-mappings", can(models.PermissionRoleManage), h.CreateOIDCRoleMapping)
+		auth.PUT("/oidc/role-mappings/:id", can(models.PermissionRoleManage), h.UpdateOIDCRoleMapping)
+		auth.DELETE("/oidc/role-mappings/:id", can(models.PermissionRoleManage), h.DeleteOIDCRoleMapping)
+
+		// Background Jobs
+		auth.GET("/jobs/runs", can(models.PermissionJobView), h.GetJobRuns)
+
+		// Unit Kerja Management
+		auth.POST("/units", can(models.PermissionUnitManage), h.CreateUnit)
+		auth.PUT("/units/:id", can(models.PermissionUnitManage), h.UpdateUnit)
+		auth.DELETE("/units/:id", can(models.PermissionUnitManage), h.DeleteUnit)
 	}
 
 	fmt.Printf("Server running on port %s\n", port)
@@ -0,0 +1,110 @@
+// Command migrate-storage copies stored files from one storage backend to
+// another, e.g. from the local uploads directory to an S3 bucket:
+//
+//	go run ./cmd/migrate-storage -from local -to s3
+//
+// Backend settings are read from the same environment as the API. Files that
+// already exist at the destination are skipped unless -overwrite is set, so
+// the command can be run again after an interruption.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"mime"
+	"path"
+
+	"github.com/dr15/internship-hub-api/config"
+	"github.com/dr15/internship-hub-api/internal/storage"
+)
+
+func main() {
+	from := flag.String("from", "local", "source storage driver (local or s3)")
+	to := flag.String("to", "s3", "destination storage driver (local or s3)")
+	fromDir := flag.String("from-dir", "", "directory of a local source (default STORAGE_LOCAL_DIR)")
+	toDir := flag.String("to-dir", "", "directory of a local destination (default STORAGE_LOCAL_DIR)")
+	prefix := flag.String("prefix", "", "only copy keys starting with this prefix")
+	overwrite := flag.Bool("overwrite", false, "replace files that already exist at the destination")
+	dryRun := flag.Bool("dry-run", false, "list the files that would be copied without copying them")
+	flag.Parse()
+
+	config.LoadConfig()
+
+	src, err := open(*from, *fromDir)
+	if err != nil {
+		log.Fatalf("Failed to open source storage: %v", err)
+	}
+	dst, err := open(*to, *toDir)
+	if err != nil {
+		log.Fatalf("Failed to open destination storage: %v", err)
+	}
+	if *from == *to && (*from != "local" || dirOf(*fromDir) == dirOf(*toDir)) {
+		log.Fatal("Source and destination are the same storage")
+	}
+
+	ctx := context.Background()
+	var copied, skipped, failed int
+	err = src.List(ctx, *prefix, func(key string) error {
+		if !*overwrite {
+			exists, err := dst.Exists(ctx, key)
+			if err != nil {
+				return err
+			}
+			if exists {
+				skipped++
+				return nil
+			}
+		}
+
+		if *dryRun {
+			fmt.Println(key)
+			copied++
+			return nil
+		}
+		if err := copyFile(ctx, src, dst, key); err != nil {
+			log.Printf("Failed to copy %s: %v", key, err)
+			failed++
+			return nil
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Migration stopped: %v", err)
+	}
+
+	verb := "copied"
+	if *dryRun {
+		verb = "to copy"
+	}
+	log.Printf("%d files %s, %d already present, %d failed", copied, verb, skipped, failed)
+	if failed > 0 {
+		log.Fatal("Some files were not copied, run the command again to retry them")
+	}
+}
+
+func open(driver, dir string) (storage.Storage, error) {
+	if driver == "local" {
+		return storage.NewLocal(dirOf(dir)), nil
+	}
+	return storage.FromConfig(driver, config.AppConfig)
+}
+
+func dirOf(dir string) string {
+	if dir == "" {
+		return config.AppConfig.StorageLocalDir
+	}
+	return dir
+}
+
+// copyFile streams one file to the destination
+func copyFile(ctx context.Context, src, dst storage.Storage, key string) error {
+	r, err := src.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return dst.Put(ctx, key, r, -1, mime.TypeByExtension(path.Ext(key)))
+}
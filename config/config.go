@@ -16,11 +16,48 @@ type Config struct {
 	DBSSLMode  string
 	JWTSecret  string
 	ServerPort string
+
+	AccessTokenTTLMinutes string
+	RefreshTokenTTLDays   string
+
+	LoginMaxFailures      string
+	LoginMaxFailuresPerIP string
+	LoginLockoutMinutes   string
+
+	OIDCIssuerURL          string
+	OIDCClientID           string
+	OIDCClientSecret       string
+	OIDCRedirectURL        string
+	OIDCScopes             string
+	OIDCFrontendURL        string
+	OIDCAllowStaffPassword string
+
 	SMTPHost   string
 	SMTPPort   string
 	SMTPUser   string
 	SMTPPass   string
 	SMTPSender string
+
+	OfferResponseDays string
+
+	SchedulerEnabled      string
+	CronCloseVacancies    string
+	CronFinishInternships string
+	CronMarkAbsent        string
+	CronExpireOffers      string
+	CronPurgeTokens       string
+
+	StorageDriver   string
+	StorageLocalDir string
+	S3Endpoint      string
+	S3Region        string
+	S3Bucket        string
+	S3AccessKey     string
+	S3SecretKey     string
+	S3PathStyle     string
+
+	FileURLSecret     string
+	FileURLTTLMinutes string
 }
 
 var AppConfig *Config
@@ -40,11 +77,52 @@ func LoadConfig() {
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 		JWTSecret:  getEnv("JWT_SECRET", "secret"),
 		ServerPort: getEnv("PORT", "8080"),
+
+		AccessTokenTTLMinutes: getEnv("ACCESS_TOKEN_TTL_MINUTES", "15"),
+		RefreshTokenTTLDays:   getEnv("REFRESH_TOKEN_TTL_DAYS", "30"),
+
+		LoginMaxFailures:      getEnv("LOGIN_MAX_FAILURES", "5"),
+		LoginMaxFailuresPerIP: getEnv("LOGIN_MAX_FAILURES_PER_IP", "20"),
+		LoginLockoutMinutes:   getEnv("LOGIN_LOCKOUT_MINUTES", "15"),
+
+		OIDCIssuerURL:          getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:           getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:       getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:        getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/auth/oidc/callback"),
+		OIDCScopes:             getEnv("OIDC_SCOPES", "openid email profile groups"),
+		OIDCFrontendURL:        getEnv("OIDC_FRONTEND_URL", "http://localhost:5173/sso/callback"),
+		OIDCAllowStaffPassword: getEnv("OIDC_ALLOW_STAFF_PASSWORD", "false"),
+
 		SMTPHost:   getEnv("SMTP_HOST", "localhost"),
 		SMTPPort:   getEnv("SMTP_PORT", "1025"),
 		SMTPUser:   getEnv("SMTP_USER", ""),
 		SMTPPass:   getEnv("SMTP_PASSWORD", ""),
 		SMTPSender: getEnv("SMTP_SENDER", "no-reply@internshiphub.com"),
+
+		OfferResponseDays: getEnv("OFFER_RESPONSE_DAYS", "7"),
+
+		SchedulerEnabled:      getEnv("SCHEDULER_ENABLED", "true"),
+		CronCloseVacancies:    getEnv("CRON_CLOSE_VACANCIES", "5 0 * * *"),
+		CronFinishInternships: getEnv("CRON_FINISH_INTERNSHIPS", "10 0 * * *"),
+		CronMarkAbsent:        getEnv("CRON_MARK_ABSENT", "30 0 * * *"),
+		CronExpireOffers:      getEnv("CRON_EXPIRE_OFFERS", "*/15 * * * *"),
+		CronPurgeTokens:       getEnv("CRON_PURGE_TOKENS", "0 3 * * *"),
+
+		StorageDriver:   getEnv("STORAGE_DRIVER", "local"),
+		StorageLocalDir: getEnv("STORAGE_LOCAL_DIR", "uploads"),
+		S3Endpoint:      getEnv("S3_ENDPOINT", "http://localhost:9000"),
+		S3Region:        getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:        getEnv("S3_BUCKET", "internship-hub"),
+		S3AccessKey:     getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:     getEnv("S3_SECRET_KEY", ""),
+		S3PathStyle:     getEnv("S3_PATH_STYLE", "true"),
+
+		FileURLSecret:     getEnv("FILE_URL_SECRET", ""),
+		FileURLTTLMinutes: getEnv("FILE_URL_TTL_MINUTES", "5"),
+	}
+	// Download links fall back to the JWT secret so they are never signed with an empty key
+	if AppConfig.FileURLSecret == "" {
+		AppConfig.FileURLSecret = AppConfig.JWTSecret
 	}
 }
 
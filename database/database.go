@@ -31,19 +31,60 @@ func ConnectDB() {
 
 	fmt.Println("Database connection established")
 
+	// The old attendance index only covered the date column, which allowed a
+	// single attendance row per day across all interns
+	if db.Migrator().HasIndex(&models.Attendance{}, "idx_attendance_user_date") {
+		if err := db.Migrator().DropIndex(&models.Attendance{}, "idx_attendance_user_date"); err != nil {
+			log.Fatal("Failed to drop legacy attendance index:", err)
+		}
+	}
+
+	// Accounts created before email verification existed are treated as verified
+	backfillVerified := !db.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+
 	// Auto Migration
 	err = db.AutoMigrate(
 		&models.UnitKerja{},
 		&models.User{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.AuthThrottle{},
+		&models.RecoveryCode{},
+		&models.OIDCLoginState{},
+		&models.OIDCRoleMapping{},
+		&models.Role{},
 		&models.Vacancy{},
+		&models.VacancyRevision{},
+		&models.VacancyFieldChange{},
+		&models.VacancyQuestion{},
 		&models.Application{},
+		&models.ApplicationAnswer{},
+		&models.ApplicationDocument{},
+		&models.ApplicationStatusHistory{},
+		&models.ApplicationRating{},
+		&models.ApplicationComment{},
+		&models.InterviewSlot{},
+		&models.Interview{},
 		&models.Attendance{},
+		&models.LogbookEntry{},
+		&models.LeaveRequest{},
+		&models.MentorEvaluation{},
+		&models.CampusSupervisor{},
 		&models.InternshipResult{},
+		&models.JobRun{},
+		&models.StoredFile{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	if backfillVerified {
+		if err := db.Model(&models.User{}).Where("email_verified_at IS NULL").
+			Update("email_verified_at", gorm.Expr("created_at")).Error; err != nil {
+			log.Fatal("Failed to mark existing users as verified:", err)
+		}
+	}
+
 	fmt.Println("Database migration completed")
 	DB = db
 }
@@ -2,8 +2,10 @@ package database
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -26,8 +28,77 @@ func SeedData(db *gorm.DB) {
 		}
 	}
 
-	// 2. Seed Admin Users
+	// 2. Seed Built-in Roles. Their permissions can be edited afterwards, so
+	// existing roles are left alone.
+	roles := []models.Role{
+		{
+			Name:        models.UserRoleApplicant,
+			Description: "Pelamar dan peserta magang",
+			Permissions: permissionNames(
+				models.PermissionApplicationApply,
+				models.PermissionInterviewBook,
+				models.PermissionAttendanceRecord,
+				models.PermissionInternshipReport,
+			),
+		},
+		{
+			Name:        models.UserRoleUnit,
+			Description: "Admin unit kerja",
+			Permissions: permissionNames(
+				models.PermissionVacancyManage,
+				models.PermissionApplicationView,
+				models.PermissionApplicationReview,
+				models.PermissionInterviewManage,
+				models.PermissionAttendanceView,
+				models.PermissionAttendanceExport,
+				models.PermissionInternshipEvaluate,
+			),
+			UnitScoped: true,
+		},
+		{
+			Name:        models.UserRoleCentral,
+			Description: "Admin pusat",
+			Permissions: permissionNames(
+				models.PermissionVacancyManage,
+				models.PermissionVacancyApprove,
+				models.PermissionApplicationView,
+				models.PermissionApplicationReview,
+				models.PermissionInterviewManage,
+				models.PermissionAttendanceView,
+				models.PermissionAttendanceExport,
+				models.PermissionInternshipEvaluate,
+				models.PermissionUserManage,
+				models.PermissionRoleManage,
+				models.PermissionUnitManage,
+				models.PermissionJobView,
+			),
+			RequireTwoFactor: true,
+		},
+		{
+			Name:        models.UserRoleMentor,
+			Description: "Mentor yang membimbing peserta magang sehari-hari",
+			Permissions: permissionNames(models.PermissionMenteeSupervise),
+			UnitScoped:  true,
+		},
+		{
+			Name:        models.UserRoleCampusSupervisor,
+			Description: "Dosen pembimbing dari kampus, hanya dapat melihat magang mahasiswa yang mengundangnya",
+			Permissions: permissionNames(models.PermissionInternshipMonitor),
+		},
+	}
+	for _, role := range roles {
+		role.System = true
+		var existing models.Role
+		if err := db.Where("name = ?", role.Name).First(&existing).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				db.Create(&role)
+			}
+		}
+	}
+
+	// 3. Seed Admin Users
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
+	now := time.Now()
 
 	// Central Admin
 	adminCentral := models.User{
@@ -35,6 +106,8 @@ func SeedData(db *gorm.DB) {
 		Email:    "admin@instansi.go.id",
 		Password: string(hashedPassword),
 		Role:     models.UserRoleCentral,
+
+		EmailVerifiedAt: &now,
 	}
 	var existingCentral models.User
 	if err := db.Where("email = ?", adminCentral.Email).First(&existingCentral).Error; err != nil {
@@ -57,6 +130,8 @@ func SeedData(db *gorm.DB) {
 			Password:    string(hashedPassword),
 			Role:        models.UserRoleUnit,
 			UnitKerjaID: &unit.ID,
+
+			EmailVerifiedAt: &now,
 		}
 
 		var existingUnit models.User
@@ -69,3 +144,11 @@ func SeedData(db *gorm.DB) {
 
 	fmt.Println("Seeding completed")
 }
+
+func permissionNames(permissions ...models.Permission) pq.StringArray {
+	names := make(pq.StringArray, len(permissions))
+	for i, p := range permissions {
+		names[i] = string(p)
+	}
+	return names
+}
@@ -1,19 +1,27 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"fmt"
-	"path/filepath"
 
 	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/dr15/internship-hub-api/internal/services"
 	"github.com/dr15/internship-hub-api/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
 )
 
+// bulkReviewLimit caps how many applications one bulk review may touch
+const bulkReviewLimit = 500
+
 type ApplicationRequest struct {
 	VacancyID  uuid.UUID `json:"vacancyId" binding:"required"`
 	Phone      string    `json:"phone" binding:"required"`
@@ -24,14 +32,42 @@ type ApplicationRequest struct {
 	CVFileName string    `json:"cvFileName" binding:"required"`
 }
 
+type WithdrawApplicationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ApplicationReviewRequest covers the decisions taken by reviewers. The other
+// statuses are reached through their own flows, e.g. accepting an offer or
+// reviewing the finished internship.
 type ApplicationReviewRequest struct {
-	Status        models.ApplicationStatus `json:"status" binding:"required"`
+	Status        models.ApplicationStatus `json:"status" binding:"required,oneof=reviewed interview waitlisted offered rejected"`
 	RejectionNote string                   `json:"rejectionNote"`
+	// OfferDeadline (YYYY-MM-DD) is the last day the applicant may respond to an offer
+	OfferDeadline string `json:"offerDeadline"`
+}
+
+// BulkReviewRequest selects applications either by ID or by Filter
+type BulkReviewRequest struct {
+	ApplicationIDs []uuid.UUID              `json:"applicationIds"`
+	Filter         *BulkReviewFilter        `json:"filter"`
+	Status         models.ApplicationStatus `json:"status" binding:"required,oneof=reviewed interview waitlisted offered rejected"`
+	RejectionNote  string                   `json:"rejectionNote"`
+	OfferDeadline  string                   `json:"offerDeadline"`
+}
+
+type BulkReviewFilter struct {
+	VacancyID uuid.UUID                `json:"vacancyId" binding:"required"`
+	Status    models.ApplicationStatus `json:"status"`
+	Search    string                   `json:"search"`
+}
+
+type DeclineOfferRequest struct {
+	Reason string `json:"reason"`
 }
 
 // SubmitApplication for applicant
 // @Summary Submit an application
-// @Description Submit a new internship application for a specific vacancy.
+// @Description Submit a new internship application for a specific vacancy. Answers to the vacancy's questions go in the 'answers' field as a JSON object keyed by question ID (a string, a number, or a list of options for multi-choice questions); files for file questions are uploaded as 'answer_<questionId>'. Supporting documents are uploaded as 'document_<type>' (transcript, recommendation_letter, id_card); the ones listed in the vacancy's requiredDocuments are mandatory. Files are checked by content: CVs must be PDF or DOCX up to 5 MB, and refused files are answered with 413 or 415 naming the form field.
 // @Tags Applications
 // @Security BearerAuth
 // @Accept json
@@ -39,7 +75,11 @@ type ApplicationReviewRequest struct {
 // @Param request body ApplicationRequest true "Application submission request"
 // @Success 201 {object} models.Application
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 409 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /applications [post]
 func (h *Handler) SubmitApplication(c *gin.Context) {
@@ -59,6 +99,30 @@ func (h *Handler) SubmitApplication(c *gin.Context) {
 
 	userId, _ := c.Get("userId")
 
+	applicant, err := h.UserRepo.FindByID(userId.(uuid.UUID).String())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if applicant.EmailVerifiedAt == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Silakan verifikasi email Anda terlebih dahulu sebelum mengirim lamaran."})
+		return
+	}
+
+	vacancy, err := h.VacancyRepo.FindByID(vacancyID.String())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
+		return
+	}
+	if vacancy.Status != models.VacancyStatusApproved {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Lowongan ini tidak sedang menerima lamaran."})
+		return
+	}
+	if vacancy.IsPastDeadline(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Batas waktu pendaftaran lowongan ini sudah lewat."})
+		return
+	}
+
 	// Check if currently in an active internship
 	count, _ := h.ApplicationRepo.CountAcceptedByUser(userId.(uuid.UUID))
 	if count > 0 {
@@ -80,16 +144,54 @@ func (h *Handler) SubmitApplication(c *gin.Context) {
 		return
 	}
 
-	// Create a unique filename
-	ext := filepath.Ext(file.Filename)
-	newFileName := fmt.Sprintf("%s-%d%s", userId.(uuid.UUID).String(), time.Now().Unix(), ext)
-	uploadPath := filepath.Join("uploads", "cv", newFileName)
+	// Every file is validated before anything is stored
+	cvUpload, err := readUpload(file, "cv", documentPolicies[models.DocumentTypeCV])
+	if err != nil {
+		if !respondUploadError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read CV file"})
+		}
+		return
+	}
 
-	if err := c.SaveUploadedFile(file, uploadPath); err != nil {
+	documentUploads, err := collectDocuments(c, vacancy)
+	if err != nil {
+		if !respondUploadError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	answers, answerUploads, err := collectAnswers(c, vacancy.Questions)
+	if err != nil {
+		if !respondUploadError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	cv, err := h.saveDocument(models.DocumentTypeCV, cvUpload)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save CV file"})
 		return
 	}
 
+	documents := []models.ApplicationDocument{cv}
+	for _, docType := range documentTypes {
+		if upload, ok := documentUploads[docType]; ok {
+			doc, err := h.saveDocument(docType, upload)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
+				return
+			}
+			documents = append(documents, doc)
+		}
+	}
+
+	if err := h.saveAnswerFiles(answers, answerUploads); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save answer file"})
+		return
+	}
+
 	application := models.Application{
 		UserID:     userId.(uuid.UUID),
 		VacancyID:  vacancyID,
@@ -98,13 +200,26 @@ func (h *Handler) SubmitApplication(c *gin.Context) {
 		Major:      major,
 		Semester:   semester,
 		Motivation: motivation,
-		CVFileName: newFileName,
+		CVFileName: cv.FileName,
 		Status:     models.ApplicationStatusSubmitted,
 		AppliedAt:  time.Now(),
+		Answers:    answers,
+		Documents:  documents,
 	}
 
-	if err := h.ApplicationRepo.Create(&application); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit application"})
+	// The vacancy and earlier applications are checked again inside the
+	// transaction in case something changed meanwhile
+	if err := h.ApplicationRepo.Submit(&application); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrVacancyNotOpen):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Lowongan ini tidak sedang menerima lamaran."})
+		case errors.Is(err, repository.ErrDeadlinePassed):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Batas waktu pendaftaran lowongan ini sudah lewat."})
+		case errors.Is(err, repository.ErrAlreadyApplied):
+			c.JSON(http.StatusConflict, gin.H{"error": "Anda sudah melamar lowongan ini."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit application"})
+		}
 		return
 	}
 
@@ -138,11 +253,12 @@ func (h *Handler) GetUserApplications(c *gin.Context) {
 
 // GetVacancyApplications for unit admin to see applicants for a vacancy
 // @Summary List vacancy applications (Admin)
-// @Description Fetch all applications for a specific vacancy (for Unit Admins).
+// @Description Fetch all applications for a specific vacancy (for Unit Admins). Withdrawn applications are listed with status 'withdrawn'. Each application carries the average reviewer rating; use sort=rating (highest first) or sort=rating_asc to order by it.
 // @Tags Applications
 // @Security BearerAuth
 // @Produce json
 // @Param vacancyId path string true "Vacancy ID"
+// @Param sort query string false "Sort order: rating, rating_asc (default: newest first)"
 // @Success 200 {array} models.Application
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
@@ -150,8 +266,6 @@ func (h *Handler) GetUserApplications(c *gin.Context) {
 // @Router /vacancies/{id}/applications [get]
 func (h *Handler) GetVacancyApplications(c *gin.Context) {
 	id := c.Param("id")
-	role, _ := c.Get("role")
-	unitId, _ := c.Get("unitKerjaId")
 	pagination := utils.GetPaginationRequest(c)
 
 	vacancy, err := h.VacancyRepo.FindByID(id)
@@ -160,14 +274,14 @@ func (h *Handler) GetVacancyApplications(c *gin.Context) {
 		return
 	}
 
-	// Permission check: Unit admin can only see applications for their own unit's vacancy
-	if role == models.UserRoleUnit && unitId != nil && (*unitId.(*uuid.UUID)).String() != vacancy.UnitKerjaID.String() {
+	if _, ok := h.authorize(c, models.PermissionApplicationView, &vacancy.UnitKerjaID); !ok {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: can only view applications for your own unit's vacancy"})
 		return
 	}
 
 	search := c.Query("search")
-	applications, total, err := h.ApplicationRepo.FindByVacancyID(id, search, pagination.Page, pagination.Limit)
+	sort := c.Query("sort")
+	applications, total, err := h.ApplicationRepo.FindByVacancyID(id, search, sort, pagination.Page, pagination.Limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch applications"})
 		return
@@ -179,9 +293,95 @@ func (h *Handler) GetVacancyApplications(c *gin.Context) {
 	})
 }
 
+// ExportVacancyApplications for unit admin
+// @Summary Export vacancy applications to Excel
+// @Description Download all applications of a vacancy as an .xlsx file, with one column per application question.
+// @Tags Applications
+// @Security BearerAuth
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path string true "Vacancy ID"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/applications/export [get]
+func (h *Handler) ExportVacancyApplications(c *gin.Context) {
+	vacancy, ok := h.findOwnedVacancy(c, c.Param("id"), models.PermissionApplicationView)
+	if !ok {
+		return
+	}
+
+	applications, err := h.ApplicationRepo.FindAllByVacancy(vacancy.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data for export"})
+		return
+	}
+
+	// One column per current question, followed by questions that were
+	// removed after someone answered them
+	var columns []uuid.UUID
+	labels := map[uuid.UUID]string{}
+	for _, q := range vacancy.Questions {
+		columns = append(columns, q.ID)
+		labels[q.ID] = q.Label
+	}
+	for _, app := range applications {
+		for _, answer := range app.Answers {
+			if _, ok := labels[answer.QuestionID]; !ok {
+				columns = append(columns, answer.QuestionID)
+				labels[answer.QuestionID] = answer.Label
+			}
+		}
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Applications"
+	f.SetSheetName("Sheet1", sheet)
+
+	headers := []string{"No", "Nama", "Email", "Telepon", "Universitas", "Jurusan", "Semester", "Motivasi", "Status", "Tanggal Melamar"}
+	for _, id := range columns {
+		headers = append(headers, labels[id])
+	}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+
+	for i, app := range applications {
+		row := []interface{}{i + 1, app.User.Name, app.User.Email, app.Phone, app.University, app.Major, app.Semester, app.Motivation, string(app.Status), app.AppliedAt.Format("2006-01-02 15:04")}
+
+		byQuestion := make(map[uuid.UUID]models.ApplicationAnswer, len(app.Answers))
+		for _, answer := range app.Answers {
+			byQuestion[answer.QuestionID] = answer
+		}
+		for _, id := range columns {
+			answer := byQuestion[id]
+			switch {
+			case answer.FileName != "":
+				row = append(row, answer.FileName)
+			case len(answer.Choices) > 0:
+				row = append(row, strings.Join(answer.Choices, ", "))
+			default:
+				row = append(row, answer.Value)
+			}
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		f.SetSheetRow(sheet, cell, &row)
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", "attachment; filename=applications.xlsx")
+	if err := f.Write(c.Writer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate Excel file"})
+	}
+}
+
 // ReviewApplication for unit admin
 // @Summary Review an application
-// @Description Update the status of an application (for Unit Admins).
+// @Description Update the status of an application (for Unit Admins). Status 'offered' (or 'accepted') makes an offer the applicant must accept before the offer deadline; once the vacancy quota is full the applicant is put on the waitlist instead, and offering to a waitlisted applicant fails with 409. Status 'waitlisted' puts the applicant at the end of the waitlist. Moves not allowed by the application state machine are rejected with 409.
 // @Tags Applications
 // @Security BearerAuth
 // @Accept json
@@ -192,6 +392,7 @@ func (h *Handler) GetVacancyApplications(c *gin.Context) {
 // @Failure 400 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /applications/{id} [patch]
 func (h *Handler) ReviewApplication(c *gin.Context) {
@@ -208,30 +409,362 @@ func (h *Handler) ReviewApplication(c *gin.Context) {
 		return
 	}
 
-	role, _ := c.Get("role")
-	unitId, _ := c.Get("unitKerjaId")
-	// Verify unit admin ownership
-	if role == models.UserRoleUnit && unitId != nil && (*unitId.(*uuid.UUID)).String() != application.Vacancy.UnitKerjaID.String() {
+	if _, ok := h.authorize(c, models.PermissionApplicationReview, &application.Vacancy.UnitKerjaID); !ok {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: application belongs to another unit's vacancy"})
 		return
 	}
 
+	// Accepting now means making an offer; the applicant confirms it themselves
+	if req.Status == models.ApplicationStatusAccepted {
+		req.Status = models.ApplicationStatusOffered
+	}
+
+	var expiresAt time.Time
+	if req.Status == models.ApplicationStatusOffered {
+		expiresAt, err = offerExpiry(req.OfferDeadline)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userId, _ := c.Get("userId")
+	result, code, message := h.applyReview(application, req.Status, req.RejectionNote, expiresAt, userId.(uuid.UUID))
+	if code != 0 {
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+
+	if result != req.Status {
+		c.JSON(http.StatusOK, gin.H{"message": "Vacancy quota is full, applicant was added to the waitlist", "status": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Application status updated successfully"})
+}
+
+// BulkReviewApplications for unit admin
+// @Summary Review many applications at once
+// @Description Move a list of applications, or all applications of a vacancy matching a filter, to the same status with a shared note. Each application is checked like a single review; the response lists the outcome of every item instead of failing all-or-nothing.
+// @Tags Applications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body BulkReviewRequest true "Bulk review request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/bulk-review [post]
+func (h *Handler) BulkReviewApplications(c *gin.Context) {
+	var req BulkReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (len(req.ApplicationIDs) == 0) == (req.Filter == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either applicationIds or filter"})
+		return
+	}
+
+	ids := req.ApplicationIDs
+	if req.Filter != nil {
+		vacancy, err := h.VacancyRepo.FindByID(req.Filter.VacancyID.String())
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
+			return
+		}
+		if _, ok := h.authorize(c, models.PermissionApplicationReview, &vacancy.UnitKerjaID); !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: vacancy belongs to another unit"})
+			return
+		}
+
+		ids, err = h.ApplicationRepo.FindIDsByVacancy(vacancy.ID.String(), req.Filter.Status, req.Filter.Search)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch applications"})
+			return
+		}
+	}
+
+	if len(ids) > bulkReviewLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d applications can be reviewed at once", bulkReviewLimit)})
+		return
+	}
+
+	// Accepting now means making an offer; the applicant confirms it themselves
 	if req.Status == models.ApplicationStatusAccepted {
-		count, err := h.ApplicationRepo.CountAcceptedByUser(application.UserID)
+		req.Status = models.ApplicationStatusOffered
+	}
+
+	var expiresAt time.Time
+	if req.Status == models.ApplicationStatusOffered {
+		var err error
+		expiresAt, err = offerExpiry(req.OfferDeadline)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing accepted applications"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+	}
+
+	userId, _ := c.Get("userId")
+	results := make([]gin.H, 0, len(ids))
+	succeeded := 0
+	for _, id := range ids {
+		application, err := h.ApplicationRepo.FindByID(id.String())
+		if err != nil {
+			results = append(results, gin.H{"applicationId": id, "success": false, "error": "Application not found"})
+			continue
+		}
+		if _, ok := h.authorize(c, models.PermissionApplicationReview, &application.Vacancy.UnitKerjaID); !ok {
+			results = append(results, gin.H{"applicationId": id, "success": false, "error": "Forbidden: application belongs to another unit's vacancy"})
+			continue
+		}
+
+		result, code, message := h.applyReview(application, req.Status, req.RejectionNote, expiresAt, userId.(uuid.UUID))
+		if code != 0 {
+			results = append(results, gin.H{"applicationId": id, "success": false, "error": message})
+			continue
+		}
+		results = append(results, gin.H{"applicationId": id, "success": true, "status": result})
+		succeeded++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     len(ids),
+		"succeeded": succeeded,
+		"failed":    len(ids) - succeeded,
+		"results":   results,
+	})
+}
+
+// applyReview moves one application to the reviewed status and returns the
+// status it ended up in; an offer on a full vacancy lands on the waitlist. When
+// the move is refused it returns the HTTP status and message to report.
+func (h *Handler) applyReview(application models.Application, status models.ApplicationStatus, note string, expiresAt time.Time, actorID uuid.UUID) (models.ApplicationStatus, int, string) {
+	id := application.ID.String()
+
+	var err error
+	result := status
+	if status == models.ApplicationStatusOffered {
+		count, countErr := h.ApplicationRepo.CountAcceptedByUser(application.UserID)
+		if countErr != nil {
+			return "", http.StatusInternalServerError, "Failed to check existing accepted applications"
+		}
 		if count > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Applicant is already accepted for another vacancy in this period"})
+			return "", http.StatusBadRequest, "Applicant is already accepted for another vacancy in this period"
+		}
+
+		// Offer re-counts the seats under a lock on the vacancy
+		result, err = h.ApplicationRepo.Offer(id, note, expiresAt, actorID)
+	} else {
+		err = h.ApplicationRepo.UpdateStatus(id, status, note, actorID)
+	}
+
+	var transitionErr *repository.InvalidTransitionError
+	switch {
+	case err == nil:
+	case errors.As(err, &transitionErr):
+		return "", http.StatusConflict, transitionErr.Error()
+	case errors.Is(err, repository.ErrQuotaFull):
+		return "", http.StatusConflict, "Vacancy quota is already full"
+	default:
+		return "", http.StatusInternalServerError, "Failed to update application status"
+	}
+
+	// Rejecting an open offer frees its seat for the waitlist
+	if application.Status == models.ApplicationStatusOffered && result == models.ApplicationStatusRejected {
+		h.Waitlist.PromoteAsync(application.VacancyID)
+	}
+	return result, 0, ""
+}
+
+// WithdrawApplication for applicant
+// @Summary Withdraw an application
+// @Description Cancel one of my applications before it is decided, or leave an accepted internship. A reason is optional. The vacancy can be applied to again afterwards, and a seat given up goes to the first applicant on the waitlist.
+// @Tags Applications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body WithdrawApplicationRequest false "Withdrawal reason"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/withdraw [post]
+func (h *Handler) WithdrawApplication(c *gin.Context) {
+	id := c.Param("id")
+	var req WithdrawApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	application, err := h.ApplicationRepo.FindByID(id)
+	if err != nil || application.UserID != userId.(uuid.UUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return
+	}
+
+	if err := h.ApplicationRepo.Withdraw(id, req.Reason, userId.(uuid.UUID)); err != nil {
+		if errors.Is(err, repository.ErrNotWithdrawable) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Lamaran yang sudah diputuskan tidak dapat dibatalkan."})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw application"})
+		return
+	}
+
+	if application.Status == models.ApplicationStatusAccepted {
+		reason := "pelamar mengundurkan diri dari magang"
+		if req.Reason != "" {
+			reason += " (" + req.Reason + ")"
+		}
+		go h.Notifier.OfferReleased(application, reason)
+		h.Waitlist.PromoteAsync(application.VacancyID)
 	}
 
-	if err := h.ApplicationRepo.UpdateStatus(id, req.Status, req.RejectionNote); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update application status"})
+	c.JSON(http.StatusOK, gin.H{"message": "Application withdrawn successfully"})
+}
+
+// AcceptOffer for applicant
+// @Summary Accept an internship offer
+// @Description Confirm an open offer before it expires. The application becomes 'accepted' and the internship starts.
+// @Tags Applications
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/offer/accept [post]
+func (h *Handler) AcceptOffer(c *gin.Context) {
+	id := c.Param("id")
+	userId, _ := c.Get("userId")
+	application, err := h.ApplicationRepo.FindByID(id)
+	if err != nil || application.UserID != userId.(uuid.UUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Application status updated successfully"})
+	if err := h.ApplicationRepo.AcceptOffer(id, userId.(uuid.UUID)); err != nil {
+		h.respondOfferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Offer accepted successfully"})
+}
+
+// DeclineOffer for applicant
+// @Summary Decline an internship offer
+// @Description Turn down an open offer. The seat is released to the waitlist and the unit is notified.
+// @Tags Applications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body DeclineOfferRequest false "Decline reason"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/offer/decline [post]
+func (h *Handler) DeclineOffer(c *gin.Context) {
+	id := c.Param("id")
+	var req DeclineOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	application, err := h.ApplicationRepo.FindByID(id)
+	if err != nil || application.UserID != userId.(uuid.UUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return
+	}
+
+	if err := h.ApplicationRepo.DeclineOffer(id, req.Reason, userId.(uuid.UUID)); err != nil {
+		h.respondOfferError(c, err)
+		return
+	}
+
+	reason := "penawaran ditolak oleh pelamar"
+	if req.Reason != "" {
+		reason += " (" + req.Reason + ")"
+	}
+	go h.Notifier.OfferReleased(application, reason)
+	h.Waitlist.PromoteAsync(application.VacancyID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Offer declined successfully"})
+}
+
+// GetApplicationHistory returns the status timeline of an application
+// @Summary Get application status history
+// @Description Fetch every status change of an application with its actor, time and note. Applicants can only see their own applications; unit admins only their unit's.
+// @Tags Applications
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {array} models.ApplicationStatusHistory
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/history [get]
+func (h *Handler) GetApplicationHistory(c *gin.Context) {
+	id := c.Param("id")
+	application, err := h.ApplicationRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return
+	}
+
+	if !h.canViewApplication(c, application) {
+		return
+	}
+
+	history, err := h.ApplicationRepo.FindStatusHistory(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch application history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *Handler) respondOfferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNoOpenOffer):
+		c.JSON(http.StatusConflict, gin.H{"error": "Tidak ada penawaran aktif untuk lamaran ini."})
+	case errors.Is(err, repository.ErrOfferExpired):
+		c.JSON(http.StatusConflict, gin.H{"error": "Batas waktu untuk menanggapi penawaran ini sudah lewat."})
+	case errors.Is(err, repository.ErrActiveInternship):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Anda sedang dalam periode magang aktif dan tidak dapat menerima penawaran lain."})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to respond to offer"})
+	}
+}
+
+// offerExpiry returns the end of the given response deadline day, or the
+// configured default response window when no deadline is given.
+func offerExpiry(deadline string) (time.Time, error) {
+	if deadline == "" {
+		return services.DefaultOfferExpiry(), nil
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", deadline, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid offer deadline format, use YYYY-MM-DD")
+	}
+	expiresAt := day.AddDate(0, 0, 1)
+	if !expiresAt.After(time.Now()) {
+		return time.Time{}, errors.New("Offer deadline must not be in the past")
+	}
+	return expiresAt, nil
 }
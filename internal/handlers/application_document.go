@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// documentTypes are the supporting documents an application can carry
+var documentTypes = []models.DocumentType{
+	models.DocumentTypeCV,
+	models.DocumentTypeTranscript,
+	models.DocumentTypeRecommendationLetter,
+	models.DocumentTypeIDCard,
+}
+
+var documentPolicies = map[models.DocumentType]utils.UploadPolicy{
+	models.DocumentTypeCV:                   utils.DocumentPolicy,
+	models.DocumentTypeTranscript:           utils.AttachmentPolicy,
+	models.DocumentTypeRecommendationLetter: utils.AttachmentPolicy,
+	models.DocumentTypeIDCard:               utils.ScanPolicy,
+}
+
+var documentLabels = map[models.DocumentType]string{
+	models.DocumentTypeCV:                   "CV",
+	models.DocumentTypeTranscript:           "transkrip nilai",
+	models.DocumentTypeRecommendationLetter: "surat pengantar kampus",
+	models.DocumentTypeIDCard:               "scan kartu identitas",
+}
+
+// ReplaceApplicationDocument for applicant
+// @Summary Upload or replace a supporting document
+// @Description Attach a document of the given type (cv, transcript, recommendation_letter or id_card) to one of my applications, replacing the previous one. Only possible while the application is still 'submitted'.
+// @Tags Applications
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param type path string true "Document type"
+// @Param file formData file true "Document file"
+// @Success 200 {object} models.ApplicationDocument
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/documents/{type} [put]
+func (h *Handler) ReplaceApplicationDocument(c *gin.Context) {
+	docType := models.DocumentType(c.Param("type"))
+	if _, ok := documentLabels[docType]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown document type"})
+		return
+	}
+
+	application, ok := h.findMyApplication(c)
+	if !ok {
+		return
+	}
+	if application.Status != models.ApplicationStatusSubmitted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dokumen hanya dapat diganti selama lamaran belum diproses."})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+
+	upload, err := readUpload(file, "file", documentPolicies[docType])
+	if err != nil {
+		if !respondUploadError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read document"})
+		}
+		return
+	}
+
+	doc, err := h.saveDocument(docType, upload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
+		return
+	}
+
+	// The replaced file is kept: stored files are shared by identical uploads
+	if err := h.ApplicationRepo.ReplaceDocument(application.ID.String(), &doc); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotEditable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Dokumen hanya dapat diganti selama lamaran belum diproses."})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// collectDocuments reads and validates the supporting documents of a
+// submission, uploaded as "document_<type>", and checks that the vacancy's
+// required ones are present. The CV keeps its own "cv" field.
+func collectDocuments(c *gin.Context, vacancy models.Vacancy) (map[models.DocumentType]*utils.Upload, error) {
+	uploads := map[models.DocumentType]*utils.Upload{}
+	for _, docType := range documentTypes {
+		if docType == models.DocumentTypeCV {
+			continue
+		}
+		field := "document_" + string(docType)
+		file, err := c.FormFile(field)
+		if err != nil {
+			continue
+		}
+		upload, err := readUpload(file, field, documentPolicies[docType])
+		if err != nil {
+			return nil, err
+		}
+		uploads[docType] = upload
+	}
+
+	for _, required := range vacancy.RequiredDocuments {
+		docType := models.DocumentType(required)
+		if _, ok := uploads[docType]; !ok && docType != models.DocumentTypeCV {
+			return nil, fmt.Errorf("Dokumen %s wajib dilampirkan.", documentLabels[docType])
+		}
+	}
+	return uploads, nil
+}
+
+// saveDocument stores a validated document. CVs stay in the cv directory where
+// CVFileName has always pointed; other documents go to documents.
+func (h *Handler) saveDocument(docType models.DocumentType, upload *utils.Upload) (models.ApplicationDocument, error) {
+	name, err := h.storeUpload(upload, documentDir(docType))
+	return models.ApplicationDocument{
+		Type:         docType,
+		FileName:     name,
+		OriginalName: filepath.Base(upload.OriginalName),
+		Size:         upload.Size(),
+		Hash:         upload.Hash,
+	}, err
+}
+
+// documentDir is the storage directory holding documents of the type
+func documentDir(docType models.DocumentType) string {
+	if docType == models.DocumentTypeCV {
+		return "cv"
+	}
+	return "documents"
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ApplicationRatingRequest struct {
+	Rating int `json:"rating" binding:"required,min=1,max=5"`
+}
+
+type ApplicationCommentRequest struct {
+	Body string `json:"body" binding:"required,max=2000"`
+}
+
+// GetApplicationReviews for admins
+// @Summary Get reviewer ratings and comments
+// @Description Fetch the private ratings and comments left by unit and central admins on an application.
+// @Tags Applications
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/reviews [get]
+func (h *Handler) GetApplicationReviews(c *gin.Context) {
+	application, ok := h.findManagedApplication(c, models.PermissionApplicationView)
+	if !ok {
+		return
+	}
+
+	ratings, err := h.ReviewRepo.FindRatings(application.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
+		return
+	}
+	comments, err := h.ReviewRepo.FindComments(application.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	var average *float64
+	if len(ratings) > 0 {
+		sum := 0
+		for _, rating := range ratings {
+			sum += rating.Rating
+		}
+		avg := float64(sum) / float64(len(ratings))
+		average = &avg
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"averageRating": average,
+		"ratings":       ratings,
+		"comments":      comments,
+	})
+}
+
+// RateApplication for admins
+// @Summary Rate an applicant
+// @Description Give an applicant a 1-5 rating. Each reviewer has one rating per application; rating again replaces it.
+// @Tags Applications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body ApplicationRatingRequest true "Rating"
+// @Success 200 {object} models.ApplicationRating
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/rating [put]
+func (h *Handler) RateApplication(c *gin.Context) {
+	var req ApplicationRatingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	application, ok := h.findManagedApplication(c, models.PermissionApplicationReview)
+	if !ok {
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	rating := models.ApplicationRating{
+		ApplicationID: application.ID,
+		ReviewerID:    userId.(uuid.UUID),
+		Rating:        req.Rating,
+	}
+	if err := h.ReviewRepo.SaveRating(&rating); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rating"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rating)
+}
+
+// DeleteApplicationRating for admins
+// @Summary Remove my rating
+// @Description Remove the rating I gave an applicant.
+// @Tags Applications
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/rating [delete]
+func (h *Handler) DeleteApplicationRating(c *gin.Context) {
+	application, ok := h.findManagedApplication(c, models.PermissionApplicationReview)
+	if !ok {
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	if err := h.ReviewRepo.DeleteRating(application.ID, userId.(uuid.UUID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rating not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rating"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rating deleted successfully"})
+}
+
+// AddApplicationComment for admins
+// @Summary Comment on an application
+// @Description Leave a private comment on an application. Comments are only visible to unit and central admins.
+// @Tags Applications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body ApplicationCommentRequest true "Comment"
+// @Success 201 {object} models.ApplicationComment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/comments [post]
+func (h *Handler) AddApplicationComment(c *gin.Context) {
+	var req ApplicationCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	application, ok := h.findManagedApplication(c, models.PermissionApplicationReview)
+	if !ok {
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	comment := models.ApplicationComment{
+		ApplicationID: application.ID,
+		AuthorID:      userId.(uuid.UUID),
+		Body:          req.Body,
+	}
+	if err := h.ReviewRepo.CreateComment(&comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// DeleteApplicationComment for admins
+// @Summary Delete a comment
+// @Description Delete one of my comments on an application.
+// @Tags Applications
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /application-comments/{id} [delete]
+func (h *Handler) DeleteApplicationComment(c *gin.Context) {
+	id := c.Param("id")
+	comment, err := h.ReviewRepo.FindCommentByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	if comment.AuthorID != userId.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: can only delete your own comments"})
+		return
+	}
+
+	if err := h.ReviewRepo.DeleteComment(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// findManagedApplication loads the application in the :id param and checks that
+// the current user holds the permission for the vacancy's unit. It writes the
+// error response and returns false when the check fails.
+func (h *Handler) findManagedApplication(c *gin.Context, permission models.Permission) (models.Application, bool) {
+	application, err := h.ApplicationRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return application, false
+	}
+
+	if _, ok := h.authorize(c, permission, &application.Vacancy.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: application belongs to another unit's vacancy"})
+		return application, false
+	}
+	return application, true
+}
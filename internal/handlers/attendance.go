@@ -29,35 +29,34 @@ func (h *Handler) CheckIn(c *gin.Context) {
 		return
 	}
 
-	// Only accepted applicants can check in
-	// Check if user has an accepted application
-	apps, _, err := h.ApplicationRepo.FindByUserID(userId.(uuid.UUID), 1, 100)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify application status"})
+	acceptedApp, ok := h.findActiveInternship(c, userId.(uuid.UUID))
+	if !ok {
 		return
 	}
 
-	var acceptedApp *models.Application
-	for _, app := range apps {
-		if app.Status == models.ApplicationStatusAccepted {
-			acceptedApp = &app
-			break
-		}
-	}
-
-	if acceptedApp == nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only accepted interns can perform attendance"})
-		return
-	}
+	now := time.Now()
 
-	// Check if already checked in today
-	_, err = h.AttendanceRepo.FindTodayByUser(userId.(uuid.UUID))
+	// Check if already checked in today. A day recorded as approved leave
+	// becomes a normal working day when the intern comes in anyway.
+	existing, err := h.AttendanceRepo.FindTodayByUser(userId.(uuid.UUID))
 	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "You have already checked in today"})
+		if existing.Status != models.AttendanceStatusLeave {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already checked in today"})
+			return
+		}
+		existing.CheckIn = &now
+		existing.Status = models.AttendanceStatusPresent
+		if req.Notes != "" {
+			existing.Notes = req.Notes
+		}
+		if err := h.AttendanceRepo.Update(&existing); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+			return
+		}
+		c.JSON(http.StatusCreated, existing)
 		return
 	}
 
-	now := time.Now()
 	attendance := models.Attendance{
 		UserID:        userId.(uuid.UUID),
 		ApplicationID: acceptedApp.ID,
@@ -75,6 +74,26 @@ func (h *Handler) CheckIn(c *gin.Context) {
 	c.JSON(http.StatusCreated, attendance)
 }
 
+// findActiveInternship returns the user's accepted application, the one
+// attendance, logbook and leave are recorded against. It writes the error
+// response when there is none.
+func (h *Handler) findActiveInternship(c *gin.Context, userID uuid.UUID) (models.Application, bool) {
+	apps, _, err := h.ApplicationRepo.FindByUserID(userID, 1, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify application status"})
+		return models.Application{}, false
+	}
+
+	for _, app := range apps {
+		if app.Status == models.ApplicationStatusAccepted {
+			return app, true
+		}
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "Only accepted interns can perform attendance"})
+	return models.Application{}, false
+}
+
 // CheckOut for intern
 func (h *Handler) CheckOut(c *gin.Context) {
 	userId, _ := c.Get("userId")
@@ -85,7 +104,7 @@ func (h *Handler) CheckOut(c *gin.Context) {
 	}
 
 	attendance, err := h.AttendanceRepo.FindTodayByUser(userId.(uuid.UUID))
-	if err != nil {
+	if err != nil || attendance.CheckIn == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No check-in record found for today"})
 		return
 	}
@@ -132,18 +151,12 @@ func (h *Handler) GetMyAttendance(c *gin.Context) {
 
 // GetAttendanceRecap for admin
 func (h *Handler) GetAttendanceRecap(c *gin.Context) {
-	role, _ := c.Get("role")
-	unitID, _ := c.Get("unitKerjaId")
 	search := c.Query("search")
 	startDate := c.Query("startDate")
 	endDate := c.Query("endDate")
 	pagination := utils.GetPaginationRequest(c)
 
-	var unitUUID *uuid.UUID
-	if role == models.UserRoleUnit && unitID != nil {
-		u := unitID.(*uuid.UUID)
-		unitUUID = u
-	}
+	unitUUID, _ := h.authorize(c, models.PermissionAttendanceView, nil)
 
 	attendances, total, err := h.AttendanceRepo.FindAllWithFilters(search, unitUUID, startDate, endDate, pagination.Page, pagination.Limit)
 	if err != nil {
@@ -184,14 +197,8 @@ func (h *Handler) ExportAttendance(c *gin.Context) {
 	userIdStr := c.Query("userId")
 	startDate := c.Query("startDate")
 	endDate := c.Query("endDate")
-	role, _ := c.Get("role")
-	unitID, _ := c.Get("unitKerjaId")
 
-	var unitUUID *uuid.UUID
-	if role == models.UserRoleUnit && unitID != nil {
-		u := unitID.(*uuid.UUID)
-		unitUUID = u
-	}
+	unitUUID, _ := h.authorize(c, models.PermissionAttendanceExport, nil)
 
 	var attendances []models.Attendance
 	var err error
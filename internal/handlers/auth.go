@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/services"
 	"github.com/dr15/internship-hub-api/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -24,7 +26,7 @@ type LoginRequest struct {
 
 // Register as an Applicant
 // @Summary Register a new applicant
-// @Description Create a new account for an internship applicant
+// @Description Create a new account for an internship applicant. The account can log in right away but must verify its email address before applying.
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -52,28 +54,32 @@ func (h *Handler) Register(c *gin.Context) {
 		Password: string(hashedPassword),
 		Role:     models.UserRoleApplicant,
 	}
+	newVerificationToken(&user)
 
 	if err := h.UserRepo.Create(&user); err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
 		return
 	}
+	sendVerification(user)
 
-	token, err := utils.GenerateToken(user)
+	tokens, err := h.startSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Registration successful",
-		"token":   token,
-		"user":    user,
+		"message":      "Registration successful. Please check your email to verify your account.",
+		"token":        tokens.Token,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+		"user":         user,
 	})
 }
 
 // Login for all roles
 // @Summary User login
-// @Description Login to get access token for all user roles
+// @Description Login to get access token for all user roles. Accounts with two-factor authentication, and central admins, get a challenge token for /login/2fa instead.
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -81,6 +87,7 @@ func (h *Handler) Register(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -89,28 +96,67 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	accountKey := services.AccountKey("login", req.Email)
+	ipKey := services.IPKey("login", c.ClientIP())
+	if h.throttled(c, accountKey, ipKey) {
+		return
+	}
+
 	user, err := h.UserRepo.FindByEmail(req.Email)
 	if err != nil {
+		h.recordFailure(accountKey, ipKey, nil)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		h.recordFailure(accountKey, ipKey, &user)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
+	if err := h.Throttle.Succeed(accountKey); err != nil {
+		log.Printf("Failed to reset login throttle for %s: %v", accountKey, err)
+	}
+
+	if user.DeactivatedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akun Anda telah dinonaktifkan. Hubungi administrator."})
+		return
+	}
+	// Staff sign in through the identity provider once single sign-on is on
+	if !user.Role.IsExternal() && !h.OIDC.StaffPasswordLogin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Silakan masuk melalui single sign-on", "ssoRequired": true})
+		return
+	}
 
-	token, err := utils.GenerateToken(user)
+	// With a second factor the password only earns a challenge token for /login/2fa
+	if h.twoFactorRequired(user) {
+		respondTwoFactorChallenge(c, user)
+		return
+	}
+
+	h.completeLogin(c, user, nil)
+}
+
+// completeLogin starts a session for the user and answers with its tokens,
+// adding any extra fields
+func (h *Handler) completeLogin(c *gin.Context, user models.User, extra gin.H) {
+	tokens, err := h.startSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-		"user":    user,
-	})
+	body := gin.H{
+		"message":      "Login successful",
+		"token":        tokens.Token,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+		"user":         user,
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.JSON(http.StatusOK, body)
 }
 
 type UpdateProfileRequest struct {
@@ -125,7 +171,7 @@ type UpdateProfileRequest struct {
 
 // Me returns current user profile
 // @Summary Get current user profile
-// @Description Fetch profile of the currently logged-in user
+// @Description Fetch profile of the currently logged-in user, with the permissions granted by their role
 // @Tags Authentication
 // @Security BearerAuth
 // @Produce json
@@ -140,6 +186,9 @@ func (h *Handler) Me(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
+	if role, ok := h.Authz.Role(user.Role); ok {
+		user.Permissions = role.Permissions
+	}
 
 	c.JSON(http.StatusOK, user)
 }
@@ -208,6 +257,14 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
+	// Every request counts, so reset emails cannot be used to flood an inbox
+	accountKey := services.AccountKey("forgot", req.Email)
+	ipKey := services.IPKey("forgot", c.ClientIP())
+	if h.throttled(c, accountKey, ipKey) {
+		return
+	}
+	h.recordFailure(accountKey, ipKey, nil)
+
 	user, err := h.UserRepo.FindByEmail(req.Email)
 	if err != nil {
 		// For security reasons, don't reveal if email exists or not
@@ -266,11 +323,16 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 	user.Password = string(hashedPassword)
 	user.ResetToken = "" // Clear token
 	user.ResetExpiry = nil
+	markTokensRevoked(&user)
 
 	if err := h.UserRepo.Update(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mereset password"})
 		return
 	}
+	if err := h.SessionRepo.RevokeAllForUser(user.ID, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengakhiri sesi lama"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password berhasil diperbarui. Silakan login kembali."})
 }
@@ -309,10 +371,27 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 	}
 
 	user.Password = string(hashedPassword)
+	markTokensRevoked(&user)
 	if err := h.UserRepo.Update(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui password"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password berhasil diperbarui"})
+	// Other sessions end with the old password; this one carries on with fresh tokens
+	if err := h.SessionRepo.RevokeAllForUser(user.ID, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengakhiri sesi lama"})
+		return
+	}
+	tokens, err := h.startSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Password berhasil diperbarui",
+		"token":        tokens.Token,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
 }
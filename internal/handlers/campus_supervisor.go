@@ -0,0 +1,549 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// supervisorInvitationTTL is how long an invitation link stays valid
+	supervisorInvitationTTL = 7 * 24 * time.Hour
+	// maxCampusSupervisors bounds the supervisors invited to one internship
+	maxCampusSupervisors = 3
+)
+
+type InviteSupervisorRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Name  string `json:"name" binding:"required"`
+}
+
+type AcceptSupervisorInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+	// Name and Password create the account when the email has none yet
+	Name     string `json:"name"`
+	Password string `json:"password" binding:"omitempty,min=6"`
+}
+
+// SupervisorInvitationResponse describes an invitation to the person opening its link
+type SupervisorInvitationResponse struct {
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	StudentName   string    `json:"studentName"`
+	VacancyTitle  string    `json:"vacancyTitle"`
+	UnitKerja     string    `json:"unitKerja"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	AccountExists bool      `json:"accountExists"`
+}
+
+// SupervisedInternship is what a campus supervisor sees of a student's internship
+type SupervisedInternship struct {
+	ApplicationID uuid.UUID                `json:"applicationId"`
+	StudentName   string                   `json:"studentName"`
+	StudentEmail  string                   `json:"studentEmail"`
+	University    string                   `json:"university"`
+	Major         string                   `json:"major"`
+	Semester      int                      `json:"semester"`
+	VacancyTitle  string                   `json:"vacancyTitle"`
+	UnitKerja     string                   `json:"unitKerja"`
+	Duration      string                   `json:"duration"`
+	Status        models.ApplicationStatus `json:"status"`
+}
+
+// SupervisedResult is the outcome of an internship as shown to a campus
+// supervisor. Scores are only given once the unit has reviewed it.
+type SupervisedResult struct {
+	ReportSubmitted           bool              `json:"reportSubmitted"`
+	ReviewedAt                *time.Time        `json:"reviewedAt"`
+	Scores                    *InternshipScores `json:"scores,omitempty"`
+	CompletionLetterAvailable bool              `json:"completionLetterAvailable"`
+}
+
+type InternshipScores struct {
+	AttendanceScore  float64 `json:"attendanceScore"`
+	PerformanceScore float64 `json:"performanceScore"`
+	ReportScore      float64 `json:"reportScore"`
+	DisciplineScore  float64 `json:"disciplineScore"`
+	OtherScore       float64 `json:"otherScore"`
+	FinalScore       float64 `json:"finalScore"`
+}
+
+// InviteCampusSupervisor godoc
+// @Summary Invite a campus supervisor
+// @Description Email a university supervisor (dosen pembimbing) an invitation to follow this internship read-only. Students invite for their own internship, unit admins for their unit's interns. Inviting a pending address again sends a fresh link.
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body InviteSupervisorRequest true "Supervisor"
+// @Success 201 {object} models.CampusSupervisor
+// @Success 200 {object} models.CampusSupervisor
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/supervisors [post]
+func (h *Handler) InviteCampusSupervisor(c *gin.Context) {
+	var req InviteSupervisorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	app, ok := h.findSupervisableApplication(c)
+	if !ok {
+		return
+	}
+	if !isInternship(app.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Supervisors can only be invited for accepted internships"})
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	status := http.StatusOK
+	invitation, err := h.CampusSupervisorRepo.FindActive(app.ID, email)
+	switch {
+	case err == nil && invitation.AcceptedAt != nil:
+		c.JSON(http.StatusConflict, gin.H{"error": "This supervisor already follows the internship"})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		count, err := h.CampusSupervisorRepo.CountActive(app.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite supervisor"})
+			return
+		}
+		if count >= maxCampusSupervisors {
+			c.JSON(http.StatusConflict, gin.H{"error": "An internship can have at most 3 campus supervisors"})
+			return
+		}
+		invitation = models.CampusSupervisor{ApplicationID: app.ID, Email: email}
+		status = http.StatusCreated
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite supervisor"})
+		return
+	}
+
+	invitation.Name = strings.TrimSpace(req.Name)
+	invitation.InvitedBy = c.MustGet("userId").(uuid.UUID)
+	invitation.Token = uuid.New().String()
+	invitation.ExpiresAt = time.Now().Add(supervisorInvitationTTL)
+	if status == http.StatusCreated {
+		err = h.CampusSupervisorRepo.Create(&invitation)
+	} else {
+		err = h.CampusSupervisorRepo.Update(&invitation)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite supervisor"})
+		return
+	}
+
+	// Reload for the names the email mentions
+	sent, err := h.CampusSupervisorRepo.FindByToken(invitation.Token)
+	if err == nil {
+		err = utils.SendSupervisorInvitationEmail(sent.Email, sent.Name, sent.Application.User.Name,
+			sent.Application.Vacancy.Title, sent.Application.Vacancy.UnitKerja.Name, sent.Token, sent.ExpiresAt)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim email undangan"})
+		return
+	}
+
+	c.JSON(status, invitation)
+}
+
+// GetCampusSupervisors godoc
+// @Summary List the campus supervisors of an internship
+// @Description List the invited and accepted campus supervisors of an application that have not been revoked
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {array} models.CampusSupervisor
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/supervisors [get]
+func (h *Handler) GetCampusSupervisors(c *gin.Context) {
+	app, ok := h.findSupervisableApplication(c)
+	if !ok {
+		return
+	}
+
+	supervisors, err := h.CampusSupervisorRepo.FindByApplication(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch supervisors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, supervisors)
+}
+
+// RevokeCampusSupervisor godoc
+// @Summary Revoke a campus supervisor
+// @Description Withdraw an invitation or end a supervisor's access to the internship
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param supervisorId path string true "Campus supervisor ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/supervisors/{supervisorId} [delete]
+func (h *Handler) RevokeCampusSupervisor(c *gin.Context) {
+	app, ok := h.findSupervisableApplication(c)
+	if !ok {
+		return
+	}
+
+	supervisor, err := h.CampusSupervisorRepo.FindByID(c.Param("supervisorId"))
+	if err != nil || supervisor.ApplicationID != app.ID || supervisor.RevokedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supervisor not found"})
+		return
+	}
+
+	now := time.Now()
+	supervisor.RevokedAt = &now
+	if err := h.CampusSupervisorRepo.Update(&supervisor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke supervisor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Supervisor access revoked"})
+}
+
+// GetSupervisorInvitation godoc
+// @Summary Look up a supervisor invitation
+// @Description Describe the invitation behind a link, and whether its email already has an account, so the page can ask for a password only when one must be created
+// @Tags Campus Supervisors
+// @Produce json
+// @Param token path string true "Invitation token"
+// @Success 200 {object} SupervisorInvitationResponse
+// @Failure 404 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Router /supervisor-invitations/{token} [get]
+func (h *Handler) GetSupervisorInvitation(c *gin.Context) {
+	invitation, ok := h.findPendingInvitation(c, c.Param("token"))
+	if !ok {
+		return
+	}
+
+	_, err := h.UserRepo.FindByEmail(invitation.Email)
+	c.JSON(http.StatusOK, SupervisorInvitationResponse{
+		Email:         invitation.Email,
+		Name:          invitation.Name,
+		StudentName:   invitation.Application.User.Name,
+		VacancyTitle:  invitation.Application.Vacancy.Title,
+		UnitKerja:     invitation.Application.Vacancy.UnitKerja.Name,
+		ExpiresAt:     invitation.ExpiresAt,
+		AccountExists: err == nil,
+	})
+}
+
+// AcceptSupervisorInvitation godoc
+// @Summary Accept a supervisor invitation
+// @Description Accept an invitation with the token from its email. A campus supervisor account is created for the invited email when it has none, which needs a password; an existing supervisor account just gains the internship. Log in afterwards as usual.
+// @Tags Campus Supervisors
+// @Accept json
+// @Produce json
+// @Param request body AcceptSupervisorInvitationRequest true "Invitation token and, for new accounts, a password"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /supervisor-invitations/accept [post]
+func (h *Handler) AcceptSupervisorInvitation(c *gin.Context) {
+	var req AcceptSupervisorInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, ok := h.findPendingInvitation(c, req.Token)
+	if !ok {
+		return
+	}
+
+	user, err := h.UserRepo.FindByEmail(invitation.Email)
+	created := false
+	switch {
+	case err == nil && user.Role != models.UserRoleCampusSupervisor:
+		// Supervisor access is kept apart from applicant and staff accounts
+		c.JSON(http.StatusConflict, gin.H{"error": "Email ini sudah terdaftar untuk akun lain. Minta undangan ke alamat email yang berbeda."})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "password is required to create your account", "accountRequired": true})
+			return
+		}
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = invitation.Name
+		}
+		// The link reached the address, which proves it
+		now := time.Now()
+		user = models.User{
+			Name:            name,
+			Email:           invitation.Email,
+			Password:        string(hashedPassword),
+			Role:            models.UserRoleCampusSupervisor,
+			EmailVerifiedAt: &now,
+		}
+		if err := h.UserRepo.Create(&user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+			return
+		}
+		created = true
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+
+	now := time.Now()
+	invitation.SupervisorID = &user.ID
+	invitation.AcceptedAt = &now
+	if err := h.CampusSupervisorRepo.Update(&invitation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Undangan diterima. Silakan login untuk memantau magang mahasiswa.", "accountCreated": created})
+}
+
+// GetSupervisedInternships godoc
+// @Summary Get the internships I supervise
+// @Description List the internships the campus supervisor accepted an invitation for
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} SupervisedInternship
+// @Failure 500 {object} map[string]string
+// @Router /supervised [get]
+func (h *Handler) GetSupervisedInternships(c *gin.Context) {
+	accesses, err := h.CampusSupervisorRepo.FindSupervised(c.MustGet("userId").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch internships"})
+		return
+	}
+
+	internships := make([]SupervisedInternship, 0, len(accesses))
+	for _, access := range accesses {
+		if access.Application == nil || !isInternship(access.Application.Status) {
+			continue
+		}
+		internships = append(internships, supervisedInternship(*access.Application))
+	}
+
+	c.JSON(http.StatusOK, internships)
+}
+
+// GetSupervisedInternship godoc
+// @Summary Get an internship I supervise
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} SupervisedInternship
+// @Failure 404 {object} map[string]string
+// @Router /supervised/{id} [get]
+func (h *Handler) GetSupervisedInternship(c *gin.Context) {
+	app, ok := h.findSupervisedInternship(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, supervisedInternship(app))
+}
+
+// GetSupervisedAttendance godoc
+// @Summary Get a supervised student's attendance
+// @Description Fetch the attendance of an internship the campus supervisor follows, newest first
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.PaginatedResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /supervised/{id}/attendance [get]
+func (h *Handler) GetSupervisedAttendance(c *gin.Context) {
+	app, ok := h.findSupervisedInternship(c)
+	if !ok {
+		return
+	}
+	h.respondAttendance(c, app.ID)
+}
+
+// GetSupervisedLogbook godoc
+// @Summary Get a supervised student's logbook
+// @Description Fetch the logbook of an internship the campus supervisor follows, newest first
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.PaginatedResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /supervised/{id}/logbook [get]
+func (h *Handler) GetSupervisedLogbook(c *gin.Context) {
+	app, ok := h.findSupervisedInternship(c)
+	if !ok {
+		return
+	}
+	h.respondLogbook(c, app.ID)
+}
+
+// GetSupervisedResult godoc
+// @Summary Get a supervised student's final result
+// @Description Fetch whether the report was submitted and, once the unit reviewed the internship, its scores
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} SupervisedResult
+// @Failure 404 {object} map[string]string
+// @Router /supervised/{id}/result [get]
+func (h *Handler) GetSupervisedResult(c *gin.Context) {
+	app, ok := h.findSupervisedInternship(c)
+	if !ok {
+		return
+	}
+
+	var response SupervisedResult
+	result, err := h.InternshipResultRepo.FindByApplicationID(app.ID)
+	if err == nil {
+		response.ReportSubmitted = result.ReportFileName != ""
+		response.ReviewedAt = result.ReviewedAt
+		response.CompletionLetterAvailable = result.CompletionLetterPath != ""
+		if result.ReviewedAt != nil {
+			response.Scores = &InternshipScores{
+				AttendanceScore:  result.AttendanceScore,
+				PerformanceScore: result.PerformanceScore,
+				ReportScore:      result.ReportScore,
+				DisciplineScore:  result.DisciplineScore,
+				OtherScore:       result.OtherScore,
+				FinalScore:       result.FinalScore,
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSupervisedCompletionLetter godoc
+// @Summary Get a download link for the completion letter
+// @Description Issue a short-lived signed link to the completion letter of an internship the campus supervisor follows
+// @Tags Campus Supervisors
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} FileLinkResponse
+// @Failure 404 {object} map[string]string
+// @Router /supervised/{id}/completion-letter [get]
+func (h *Handler) GetSupervisedCompletionLetter(c *gin.Context) {
+	app, ok := h.findSupervisedInternship(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.InternshipResultRepo.FindByApplicationID(app.ID)
+	if err != nil || result.CompletionLetterPath == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, fileLink(result.CompletionLetterPath, result.CompletionLetterPath))
+}
+
+// findSupervisableApplication loads an application whose campus supervisors
+// the current user may manage: their own, or one of a unit whose applications
+// they review
+func (h *Handler) findSupervisableApplication(c *gin.Context) (models.Application, bool) {
+	app, err := h.ApplicationRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return app, false
+	}
+
+	if app.UserID == c.MustGet("userId").(uuid.UUID) {
+		return app, true
+	}
+	if _, ok := h.authorize(c, models.PermissionApplicationReview, &app.Vacancy.UnitKerjaID); ok {
+		return app, true
+	}
+	if _, ok := h.authorize(c, models.PermissionApplicationReview, nil); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: application belongs to another unit's vacancy"})
+		return app, false
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+	return app, false
+}
+
+// findSupervisedInternship loads an internship the current campus supervisor
+// has access to. Anything else is reported as not found, so supervisors
+// cannot learn about other applications.
+func (h *Handler) findSupervisedInternship(c *gin.Context) (models.Application, bool) {
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Internship not found"})
+		return models.Application{}, false
+	}
+
+	access, err := h.CampusSupervisorRepo.FindAccess(appID, c.MustGet("userId").(uuid.UUID))
+	if err != nil || access.Application == nil || !isInternship(access.Application.Status) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Internship not found"})
+		return models.Application{}, false
+	}
+	return *access.Application, true
+}
+
+// findPendingInvitation loads an invitation that can still be accepted. It
+// writes the error response otherwise.
+func (h *Handler) findPendingInvitation(c *gin.Context, token string) (models.CampusSupervisor, bool) {
+	invitation, err := h.CampusSupervisorRepo.FindByToken(token)
+	if err != nil || invitation.AcceptedAt != nil || invitation.RevokedAt != nil || invitation.Application == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Undangan tidak valid atau sudah digunakan"})
+		return invitation, false
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "Undangan sudah kedaluwarsa, silakan minta undangan baru"})
+		return invitation, false
+	}
+	return invitation, true
+}
+
+func supervisedInternship(app models.Application) SupervisedInternship {
+	return SupervisedInternship{
+		ApplicationID: app.ID,
+		StudentName:   app.User.Name,
+		StudentEmail:  app.User.Email,
+		University:    app.University,
+		Major:         app.Major,
+		Semester:      app.Semester,
+		VacancyTitle:  app.Vacancy.Title,
+		UnitKerja:     app.Vacancy.UnitKerja.Name,
+		Duration:      app.Vacancy.Duration,
+		Status:        app.Status,
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// verificationTTL is how long an email verification link stays valid
+	verificationTTL = 24 * time.Hour
+	// verificationResendInterval is the minimum wait between verification emails
+	verificationResendInterval = time.Minute
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm the email address of a new account with the token from the verification email
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /verify-email [post]
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.UserRepo.FindByVerificationToken(req.Token)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token tidak valid atau sudah kadaluarsa"})
+		return
+	}
+	if user.VerificationSentAt == nil || time.Since(*user.VerificationSentAt) > verificationTTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token sudah kadaluarsa, silakan minta email verifikasi baru"})
+		return
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	user.VerificationToken = ""
+	user.VerificationSentAt = nil
+	if err := h.UserRepo.Update(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memverifikasi email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email berhasil diverifikasi"})
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new verification link to the current user's email address. The previous link stops working.
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /verify-email/resend [post]
+func (h *Handler) ResendVerification(c *gin.Context) {
+	userId, _ := c.Get("userId")
+	user, err := h.UserRepo.FindByID(userId.(uuid.UUID).String())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email sudah terverifikasi"})
+		return
+	}
+	if user.VerificationSentAt != nil && time.Since(*user.VerificationSentAt) < verificationResendInterval {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Email verifikasi baru saja dikirim. Silakan tunggu sebentar sebelum meminta lagi."})
+		return
+	}
+
+	newVerificationToken(&user)
+	if err := h.UserRepo.Update(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses permintaan"})
+		return
+	}
+	if err := utils.SendVerificationEmail(user.Email, user.Name, user.VerificationToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim email verifikasi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verifikasi telah dikirim ulang"})
+}
+
+// newVerificationToken gives the user a fresh verification token, replacing any earlier one
+func newVerificationToken(user *models.User) {
+	now := time.Now()
+	user.VerificationToken = uuid.New().String()
+	user.VerificationSentAt = &now
+}
+
+// sendVerification emails the verification link to a newly registered user.
+// A failure is only logged: the account exists and the link can be resent.
+func sendVerification(user models.User) {
+	if err := utils.SendVerificationEmail(user.Email, user.Name, user.VerificationToken); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+	}
+}
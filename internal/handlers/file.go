@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/storage"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FileLinkResponse is a short-lived download link for a stored file
+type FileLinkResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// GetApplicationFileLink godoc
+// @Summary Get a download link for an application file
+// @Description Issue a short-lived signed link to one of the application's files: cv, transcript, recommendation_letter, id_card, report, completion_letter or certificate. Applicants may only download their own files and unit admins only files of their unit's applications.
+// @Tags Files
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param file path string true "File kind"
+// @Success 200 {object} FileLinkResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /applications/{id}/files/{file} [get]
+func (h *Handler) GetApplicationFileLink(c *gin.Context) {
+	app, ok := h.findViewableApplication(c)
+	if !ok {
+		return
+	}
+
+	file := c.Param("file")
+	var key, name string
+	switch file {
+	case "report", "completion_letter", "certificate":
+		result, err := h.InternshipResultRepo.FindByApplicationID(app.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		switch file {
+		case "report":
+			name = result.ReportFileName
+		case "completion_letter":
+			name = result.CompletionLetterPath
+		case "certificate":
+			name = result.CertificatePath
+		}
+		key = name
+	default:
+		docType := models.DocumentType(file)
+		if _, ok := documentPolicies[docType]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown file: " + file})
+			return
+		}
+		for _, doc := range app.Documents {
+			if doc.Type == docType {
+				key, name = storage.Key(documentDir(docType), doc.FileName), doc.OriginalName
+			}
+		}
+		// CVs uploaded before documents were tracked only have the file name on the application
+		if key == "" && docType == models.DocumentTypeCV && app.CVFileName != "" {
+			key, name = storage.Key("cv", app.CVFileName), app.CVFileName
+		}
+	}
+
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	c.JSON(http.StatusOK, fileLink(key, name))
+}
+
+// GetAnswerFileLink godoc
+// @Summary Get a download link for a file answer
+// @Description Issue a short-lived signed link to the file given as the answer to a vacancy question
+// @Tags Files
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param questionId path string true "Question ID"
+// @Success 200 {object} FileLinkResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /applications/{id}/answers/{questionId}/file [get]
+func (h *Handler) GetAnswerFileLink(c *gin.Context) {
+	app, ok := h.findViewableApplication(c)
+	if !ok {
+		return
+	}
+
+	for _, answer := range app.Answers {
+		if answer.QuestionID.String() == c.Param("questionId") && answer.FileName != "" {
+			c.JSON(http.StatusOK, fileLink(storage.Key("answers", answer.FileName), answer.FileName))
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+}
+
+// DownloadFile godoc
+// @Summary Download a file through a signed link
+// @Description Stream a stored file. The query string must come from one of the download link endpoints and expires after a few minutes.
+// @Tags Files
+// @Produce octet-stream
+// @Param key query string true "Storage key"
+// @Param name query string false "Download file name"
+// @Param expires query string true "Expiry (unix seconds)"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Router /files/download [get]
+func (h *Handler) DownloadFile(c *gin.Context) {
+	key, name := c.Query("key"), c.Query("name")
+	err := utils.VerifyFileLink(key, name, c.Query("expires"), c.Query("signature"))
+	if errors.Is(err, utils.ErrLinkExpired) {
+		c.JSON(http.StatusGone, gin.H{"error": "Link download sudah kedaluwarsa, silakan minta link baru."})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Link download tidak valid."})
+		return
+	}
+
+	file, err := h.Storage.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if name == "" {
+		name = path.Base(key)
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, file, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": name}),
+		"Cache-Control":          "private, no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// findViewableApplication loads the application in the id path parameter with
+// its files, answering 404 or 403 when the caller may not see it.
+func (h *Handler) findViewableApplication(c *gin.Context) (models.Application, bool) {
+	app, err := h.ApplicationRepo.FindWithFiles(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return app, false
+	}
+	return app, h.canViewApplication(c, app)
+}
+
+// canViewApplication lets applicants see their own applications and staff
+// those of vacancies they may view applications for. It writes 404 to those
+// who may not view applications at all, 403 to those limited to another
+// unit, and returns false when the check fails.
+func (h *Handler) canViewApplication(c *gin.Context, app models.Application) bool {
+	userId, _ := c.Get("userId")
+	if app.UserID == userId.(uuid.UUID) {
+		return true
+	}
+	if _, ok := h.authorize(c, models.PermissionApplicationView, &app.Vacancy.UnitKerjaID); ok {
+		return true
+	}
+	if _, ok := h.authorize(c, models.PermissionApplicationView, nil); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: application belongs to another unit's vacancy"})
+		return false
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+	return false
+}
+
+func fileLink(key, name string) FileLinkResponse {
+	expiresAt := time.Now().Add(utils.FileLinkTTL())
+	return FileLinkResponse{
+		URL:       "/api/files/download?" + utils.SignFileLink(key, name, expiresAt),
+		ExpiresAt: expiresAt,
+	}
+}
@@ -1,28 +1,74 @@
 package handlers
 
 import (
+	"github.com/dr15/internship-hub-api/internal/middleware"
+	"github.com/dr15/internship-hub-api/internal/models"
 	"github.com/dr15/internship-hub-api/internal/repository"
 	"github.com/dr15/internship-hub-api/internal/services"
+	"github.com/dr15/internship-hub-api/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
 	UserRepo             repository.UserRepository
+	TokenRepo            repository.RefreshTokenRepository
+	SessionRepo          repository.SessionRepository
+	TwoFactorRepo        repository.TwoFactorRepository
+	OIDCRepo             repository.OIDCRepository
+	RoleRepo             repository.RoleRepository
 	VacancyRepo          repository.VacancyRepository
 	ApplicationRepo      repository.ApplicationRepository
 	AttendanceRepo       repository.AttendanceRepository
 	UnitKerjaRepo        repository.UnitKerjaRepository
 	InternshipResultRepo repository.InternshipResultRepository
+	JobRunRepo           repository.JobRunRepository
+	InterviewRepo        repository.InterviewRepository
+	ReviewRepo           repository.ApplicationReviewRepository
+	MentorshipRepo       repository.MentorshipRepository
+	CampusSupervisorRepo repository.CampusSupervisorRepository
+	FileRepo             repository.StoredFileRepository
+	Storage              storage.Storage
 	PDFService           *services.PDFService
+	Notifier             *services.NotificationService
+	Waitlist             *services.WaitlistService
+	Throttle             *services.AuthThrottleService
+	OIDC                 *services.OIDCService
+	Authz                *services.AuthorizationService
 }
 
-func NewHandler(userRepo repository.UserRepository, vacancyRepo repository.VacancyRepository, appRepo repository.ApplicationRepository, attendanceRepo repository.AttendanceRepository, unitRepo repository.UnitKerjaRepository, resultRepo repository.InternshipResultRepository, pdfService *services.PDFService) *Handler {
+func NewHandler(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository, sessionRepo repository.SessionRepository, twoFactorRepo repository.TwoFactorRepository, oidcRepo repository.OIDCRepository, roleRepo repository.RoleRepository, vacancyRepo repository.VacancyRepository, appRepo repository.ApplicationRepository, attendanceRepo repository.AttendanceRepository, unitRepo repository.UnitKerjaRepository, resultRepo repository.InternshipResultRepository, jobRunRepo repository.JobRunRepository, interviewRepo repository.InterviewRepository, reviewRepo repository.ApplicationReviewRepository, mentorshipRepo repository.MentorshipRepository, campusSupervisorRepo repository.CampusSupervisorRepository, fileRepo repository.StoredFileRepository, store storage.Storage, pdfService *services.PDFService, notifier *services.NotificationService, waitlist *services.WaitlistService, throttle *services.AuthThrottleService, oidc *services.OIDCService, authz *services.AuthorizationService) *Handler {
 	return &Handler{
 		UserRepo:             userRepo,
+		TokenRepo:            tokenRepo,
+		SessionRepo:          sessionRepo,
+		TwoFactorRepo:        twoFactorRepo,
+		OIDCRepo:             oidcRepo,
+		RoleRepo:             roleRepo,
 		VacancyRepo:          vacancyRepo,
 		ApplicationRepo:      appRepo,
 		AttendanceRepo:       attendanceRepo,
 		UnitKerjaRepo:        unitRepo,
 		InternshipResultRepo: resultRepo,
+		JobRunRepo:           jobRunRepo,
+		InterviewRepo:        interviewRepo,
+		ReviewRepo:           reviewRepo,
+		MentorshipRepo:       mentorshipRepo,
+		CampusSupervisorRepo: campusSupervisorRepo,
+		FileRepo:             fileRepo,
+		Storage:              store,
 		PDFService:           pdfService,
+		Notifier:             notifier,
+		Waitlist:             waitlist,
+		Throttle:             throttle,
+		OIDC:                 oidc,
+		Authz:                authz,
 	}
 }
+
+// authorize checks that the current user's role grants the permission, for
+// the given unit when one is given. It returns the unit the user is limited
+// to, nil when they act across all units.
+func (h *Handler) authorize(c *gin.Context, permission models.Permission, unitID *uuid.UUID) (*uuid.UUID, bool) {
+	return h.Authz.Authorize(middleware.CurrentPrincipal(c), permission, unitID)
+}
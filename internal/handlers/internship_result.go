@@ -1,24 +1,25 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"path/filepath"
 	"time"
 
 	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
 	"github.com/dr15/internship-hub-api/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type ReviewInternshipRequest struct {
-	AttendanceScore  float64 `json:"attendanceScore" binding:"required,min=0,max=100"`
-	PerformanceScore float64 `json:"performanceScore" binding:"required,min=0,max=100"`
-	ReportScore      float64 `json:"reportScore" binding:"required,min=0,max=100"`
-	DisciplineScore  float64 `json:"disciplineScore" binding:"required,min=0,max=100"`
-	OtherScore       float64 `json:"otherScore" binding:"min=0,max=100"`
-	ReviewNotes      string  `json:"reviewNotes"`
+	AttendanceScore float64 `json:"attendanceScore" binding:"required,min=0,max=100"`
+	// PerformanceScore and DisciplineScore default to the mentor's input
+	PerformanceScore *float64 `json:"performanceScore" binding:"omitempty,min=0,max=100"`
+	ReportScore      float64  `json:"reportScore" binding:"required,min=0,max=100"`
+	DisciplineScore  *float64 `json:"disciplineScore" binding:"omitempty,min=0,max=100"`
+	OtherScore       float64  `json:"otherScore" binding:"min=0,max=100"`
+	ReviewNotes      string   `json:"reviewNotes"`
 }
 
 // SubmitReport handles applicant uploading their final internship report
@@ -31,10 +32,10 @@ func (h *Handler) SubmitReport(c *gin.Context) {
 		return
 	}
 
-	// Verify application belongs to user and is accepted
-	// In a real scenario, we might want to check if the internship period has ended
+	// Verify application belongs to user and is accepted or its period has just finished
 	app, err := h.ApplicationRepo.FindByID(appID.String())
-	if err != nil || app.UserID != userID || app.Status != models.ApplicationStatusAccepted {
+	if err != nil || app.UserID != userID ||
+		(app.Status != models.ApplicationStatusAccepted && app.Status != models.ApplicationStatusFinished) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot submit a report for this application"})
 		return
 	}
@@ -45,9 +46,17 @@ func (h *Handler) SubmitReport(c *gin.Context) {
 		return
 	}
 
+	upload, err := readUpload(file, "report", utils.ReportPolicy)
+	if err != nil {
+		if !respondUploadError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read report file"})
+		}
+		return
+	}
+
 	// Save file
-	filename := fmt.Sprintf("report_%s_%d%s", appID.String(), time.Now().Unix(), filepath.Ext(file.Filename))
-	if err := c.SaveUploadedFile(file, filepath.Join("uploads", filename)); err != nil {
+	filename, err := h.storeUpload(upload, "")
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save report file"})
 		return
 	}
@@ -93,8 +102,7 @@ func (h *Handler) ReviewInternship(c *gin.Context) {
 		return
 	}
 
-	// Fetch result with preloads to verify unit access if needed
-	// In this implementation, we rely on the middleware to check if it's a unit or central admin
+	// Fetch result with preloads to verify unit access
 	result, err := h.InternshipResultRepo.FindByApplicationID(resultID) // Wait, the param is result ID or application ID? Let's use application ID for consistency with UI flow
 	if err != nil {
 		// Try finding by UUID directly if the repo supports it, otherwise find by app ID
@@ -103,10 +111,35 @@ func (h *Handler) ReviewInternship(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Internship result not found"})
 		return
 	}
+	if _, ok := h.authorize(c, models.PermissionInternshipEvaluate, &result.Application.Vacancy.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: intern belongs to another unit"})
+		return
+	}
+
+	// Checked up front so a second review does not regenerate the documents
+	if !result.Application.Status.CanTransitionTo(models.ApplicationStatusCompleted) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This internship cannot be reviewed in its current status: " + string(result.Application.Status)})
+		return
+	}
+
+	// The admin has the final say; the mentor's scores only fill in what they leave out
+	performanceScore, disciplineScore := req.PerformanceScore, req.DisciplineScore
+	if evaluation := result.MentorEvaluation; evaluation != nil {
+		if performanceScore == nil {
+			performanceScore = &evaluation.PerformanceScore
+		}
+		if disciplineScore == nil {
+			disciplineScore = &evaluation.DisciplineScore
+		}
+	}
+	if performanceScore == nil || disciplineScore == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "performanceScore and disciplineScore are required when the mentor has not scored the intern"})
+		return
+	}
 
 	// Calculate final score (simple average for now)
 	count := 4.0
-	total := req.AttendanceScore + req.PerformanceScore + req.ReportScore + req.DisciplineScore
+	total := req.AttendanceScore + *performanceScore + req.ReportScore + *disciplineScore
 	if req.OtherScore > 0 {
 		total += req.OtherScore
 		count += 1.0
@@ -115,9 +148,9 @@ func (h *Handler) ReviewInternship(c *gin.Context) {
 
 	now := time.Now()
 	result.AttendanceScore = req.AttendanceScore
-	result.PerformanceScore = req.PerformanceScore
+	result.PerformanceScore = *performanceScore
 	result.ReportScore = req.ReportScore
-	result.DisciplineScore = req.DisciplineScore
+	result.DisciplineScore = *disciplineScore
 	result.OtherScore = req.OtherScore
 	result.FinalScore = finalScore
 	result.ReviewNotes = req.ReviewNotes
@@ -135,15 +168,17 @@ func (h *Handler) ReviewInternship(c *gin.Context) {
 		result.CertificatePath = certificatePath
 	}
 
-	if err := h.InternshipResultRepo.Update(result); err != nil {
+	// The result and the completed status are saved together
+	var transitionErr *repository.InvalidTransitionError
+	if err := h.InternshipResultRepo.SaveReview(result, adminID); err != nil {
+		if errors.As(err, &transitionErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": transitionErr.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
 		return
 	}
-
-	// Update application status to completed
-	app, _ := h.ApplicationRepo.FindByID(result.ApplicationID.String())
-	app.Status = models.ApplicationStatusCompleted
-	h.ApplicationRepo.Update(&app)
+	result.Application.Status = models.ApplicationStatusCompleted
 
 	c.JSON(http.StatusOK, gin.H{"message": "Review submitted and documents generated", "data": result})
 }
@@ -162,16 +197,9 @@ func (h *Handler) GetMyInternshipResult(c *gin.Context) {
 
 // GetInternshipResultsForAdmin lists submissions for review
 func (h *Handler) GetInternshipResultsForAdmin(c *gin.Context) {
-	role := c.MustGet("role").(models.UserRole)
 	var unitKerjaID uuid.UUID
-
-	if role == models.UserRoleUnit {
-		uID := c.MustGet("unitKerjaId")
-		if uID != nil {
-			if ptr, ok := uID.(*uuid.UUID); ok && ptr != nil {
-				unitKerjaID = *ptr
-			}
-		}
+	if scope, _ := h.authorize(c, models.PermissionInternshipEvaluate, nil); scope != nil {
+		unitKerjaID = *scope
 	}
 
 	search := c.Query("search")
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dr15/internship-hub-api/config"
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type InterviewSlotRequest struct {
+	StartsAt time.Time `json:"startsAt" binding:"required"`
+	EndsAt   time.Time `json:"endsAt" binding:"required"`
+	Location string    `json:"location" binding:"required"`
+	Capacity int       `json:"capacity" binding:"min=0"`
+}
+
+type InterviewInviteRequest struct {
+	ApplicationIDs []uuid.UUID `json:"applicationIds" binding:"required,min=1"`
+}
+
+type InterviewBookingRequest struct {
+	SlotID uuid.UUID `json:"slotId" binding:"required"`
+}
+
+type InterviewOutcomeRequest struct {
+	Outcome models.InterviewOutcome `json:"outcome" binding:"required,oneof=passed failed no_show"`
+	Notes   string                  `json:"notes"`
+}
+
+// CreateInterviewSlot for unit admin
+// @Summary Create an interview slot
+// @Description Offer a time window for interviews on a vacancy. Capacity is the number of applicants that can book it (default 1).
+// @Tags Interviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Param request body InterviewSlotRequest true "Interview slot"
+// @Success 201 {object} models.InterviewSlot
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/interview-slots [post]
+func (h *Handler) CreateInterviewSlot(c *gin.Context) {
+	var req InterviewSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vacancy, ok := h.findOwnedVacancy(c, c.Param("id"), models.PermissionInterviewManage)
+	if !ok {
+		return
+	}
+
+	if !req.EndsAt.After(req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slot must end after it starts"})
+		return
+	}
+	if !req.StartsAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slot must start in the future"})
+		return
+	}
+	if req.Capacity == 0 {
+		req.Capacity = 1
+	}
+
+	userId, _ := c.Get("userId")
+	slot := models.InterviewSlot{
+		VacancyID: vacancy.ID,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Location:  req.Location,
+		Capacity:  req.Capacity,
+		CreatedBy: userId.(uuid.UUID),
+	}
+
+	if err := h.InterviewRepo.CreateSlot(&slot); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create interview slot"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, slot)
+}
+
+// GetInterviewSlots for unit admin
+// @Summary List interview slots of a vacancy
+// @Description Fetch all interview slots of a vacancy with the number of bookings of each.
+// @Tags Interviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Success 200 {array} models.InterviewSlot
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/interview-slots [get]
+func (h *Handler) GetInterviewSlots(c *gin.Context) {
+	vacancy, ok := h.findOwnedVacancy(c, c.Param("id"), models.PermissionInterviewManage)
+	if !ok {
+		return
+	}
+
+	slots, err := h.InterviewRepo.FindSlotsByVacancy(vacancy.ID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch interview slots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, slots)
+}
+
+// DeleteInterviewSlot for unit admin
+// @Summary Delete an interview slot
+// @Description Remove an interview slot that has not been booked yet.
+// @Tags Interviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Interview slot ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /interview-slots/{id} [delete]
+func (h *Handler) DeleteInterviewSlot(c *gin.Context) {
+	id := c.Param("id")
+	slot, err := h.InterviewRepo.FindSlotByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Interview slot not found"})
+		return
+	}
+
+	if _, ok := h.findOwnedVacancy(c, slot.VacancyID.String(), models.PermissionInterviewManage); !ok {
+		return
+	}
+
+	if err := h.InterviewRepo.DeleteSlot(id); err != nil {
+		if errors.Is(err, repository.ErrSlotBooked) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Interview slot already has bookings"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete interview slot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Interview slot deleted successfully"})
+}
+
+// InviteToInterview for unit admin
+// @Summary Invite applicants to an interview
+// @Description Move shortlisted applications of a vacancy to the 'interview' status so the applicants can book a slot. Returns the result for each application.
+// @Tags Interviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Param request body InterviewInviteRequest true "Applications to invite"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /vacancies/{id}/interviews/invite [post]
+func (h *Handler) InviteToInterview(c *gin.Context) {
+	var req InterviewInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vacancy, ok := h.findOwnedVacancy(c, c.Param("id"), models.PermissionInterviewManage)
+	if !ok {
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	results := make([]gin.H, 0, len(req.ApplicationIDs))
+	for _, appID := range req.ApplicationIDs {
+		application, err := h.ApplicationRepo.FindByID(appID.String())
+		if err != nil || application.VacancyID != vacancy.ID {
+			results = append(results, gin.H{"applicationId": appID, "success": false, "error": "Application not found for this vacancy"})
+			continue
+		}
+
+		var transitionErr *repository.InvalidTransitionError
+		if _, err := h.InterviewRepo.Invite(appID, userId.(uuid.UUID)); err != nil {
+			message := "Failed to invite applicant"
+			if errors.As(err, &transitionErr) {
+				message = transitionErr.Error()
+			}
+			results = append(results, gin.H{"applicationId": appID, "success": false, "error": message})
+			continue
+		}
+		results = append(results, gin.H{"applicationId": appID, "success": true})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// RecordInterviewOutcome for unit admin
+// @Summary Record an interview outcome
+// @Description Attach the outcome and interviewer notes to an application's interview. The notes are only visible to admins.
+// @Tags Interviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body InterviewOutcomeRequest true "Interview outcome"
+// @Success 200 {object} models.Interview
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/interview [patch]
+func (h *Handler) RecordInterviewOutcome(c *gin.Context) {
+	var req InterviewOutcomeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	application, ok := h.findManagedApplication(c, models.PermissionInterviewManage)
+	if !ok {
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	interview, err := h.InterviewRepo.RecordOutcome(application.ID, req.Outcome, req.Notes, userId.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Applicant has not been invited to an interview"})
+			return
+		}
+		if errors.Is(err, repository.ErrNotScheduled) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Interview has not been booked or already has an outcome"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record interview outcome"})
+		return
+	}
+
+	c.JSON(http.StatusOK, interview)
+}
+
+// GetMyInterview for applicant
+// @Summary Get my interview
+// @Description Fetch the interview invitation of one of my applications and the booked slot, if any.
+// @Tags Interviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /applications/{id}/interview [get]
+func (h *Handler) GetMyInterview(c *gin.Context) {
+	application, ok := h.findMyApplication(c)
+	if !ok {
+		return
+	}
+
+	interview, err := h.InterviewRepo.FindByApplicationID(application.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anda belum diundang wawancara untuk lamaran ini."})
+		return
+	}
+
+	// Interviewer notes and outcome are internal to the unit
+	c.JSON(http.StatusOK, gin.H{
+		"id":            interview.ID,
+		"applicationId": interview.ApplicationID,
+		"status":        interview.Status,
+		"slot":          interview.Slot,
+	})
+}
+
+// GetAvailableInterviewSlots for applicant
+// @Summary List bookable interview slots
+// @Description Fetch the upcoming interview slots of the vacancy I applied to that still have room.
+// @Tags Interviews
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {array} models.InterviewSlot
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/interview/slots [get]
+func (h *Handler) GetAvailableInterviewSlots(c *gin.Context) {
+	application, ok := h.findMyApplication(c)
+	if !ok {
+		return
+	}
+
+	slots, err := h.InterviewRepo.FindSlotsByVacancy(application.VacancyID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch interview slots"})
+		return
+	}
+
+	available := make([]models.InterviewSlot, 0, len(slots))
+	for _, slot := range slots {
+		if slot.BookedCount < int64(slot.Capacity) {
+			available = append(available, slot)
+		}
+	}
+
+	c.JSON(http.StatusOK, available)
+}
+
+// BookInterviewSlot for applicant
+// @Summary Book or reschedule an interview
+// @Description Pick an interview slot, or move an existing booking to another slot. A calendar invitation (.ics) is emailed for each booking.
+// @Tags Interviews
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body InterviewBookingRequest true "Slot to book"
+// @Success 200 {object} models.Interview
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/interview/book [post]
+func (h *Handler) BookInterviewSlot(c *gin.Context) {
+	var req InterviewBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	application, ok := h.findMyApplication(c)
+	if !ok {
+		return
+	}
+	if application.Status != models.ApplicationStatusInterview {
+		c.JSON(http.StatusConflict, gin.H{"error": "Lamaran ini tidak sedang dalam tahap wawancara."})
+		return
+	}
+
+	interview, err := h.InterviewRepo.Book(application.ID, req.SlotID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotInvited):
+			c.JSON(http.StatusConflict, gin.H{"error": "Anda belum diundang wawancara untuk lamaran ini."})
+		case errors.Is(err, repository.ErrSlotUnavailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Jadwal wawancara tidak tersedia."})
+		case errors.Is(err, repository.ErrSlotFull):
+			c.JSON(http.StatusConflict, gin.H{"error": "Jadwal wawancara sudah penuh."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to book interview slot"})
+		}
+		return
+	}
+
+	if user, err := h.UserRepo.FindByID(application.UserID.String()); err == nil {
+		invite := interviewInvite(*interview, application.Vacancy, user.Email)
+		go utils.SendInterviewBookedEmail(user.Email, user.Name, application.Vacancy.Title, interview.Slot.StartsAt, interview.Slot.Location, invite)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":            interview.ID,
+		"applicationId": interview.ApplicationID,
+		"status":        interview.Status,
+		"slot":          interview.Slot,
+	})
+}
+
+// DownloadInterviewInvite for applicant
+// @Summary Download interview calendar invitation
+// @Description Download the .ics calendar invitation of my booked interview.
+// @Tags Interviews
+// @Security BearerAuth
+// @Produce text/calendar
+// @Param id path string true "Application ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Router /applications/{id}/interview/ics [get]
+func (h *Handler) DownloadInterviewInvite(c *gin.Context) {
+	application, ok := h.findMyApplication(c)
+	if !ok {
+		return
+	}
+
+	interview, err := h.InterviewRepo.FindByApplicationID(application.ID)
+	if err != nil || interview.Slot == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Belum ada jadwal wawancara yang dipilih."})
+		return
+	}
+
+	email, _ := c.Get("email")
+	invite := interviewInvite(*interview, application.Vacancy, email.(string))
+
+	c.Header("Content-Disposition", "attachment; filename=interview.ics")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", invite)
+}
+
+// findOwnedVacancy loads a vacancy and checks that the current user holds the
+// permission for its unit. It writes the error response and returns false
+// when the check fails.
+func (h *Handler) findOwnedVacancy(c *gin.Context, id string, permission models.Permission) (models.Vacancy, bool) {
+	vacancy, err := h.VacancyRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
+		return vacancy, false
+	}
+
+	if _, ok := h.authorize(c, permission, &vacancy.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: vacancy belongs to another unit"})
+		return vacancy, false
+	}
+	return vacancy, true
+}
+
+// findMyApplication loads the application in the :id param if it belongs to the
+// current applicant. It writes a 404 response and returns false otherwise.
+func (h *Handler) findMyApplication(c *gin.Context) (models.Application, bool) {
+	userId, _ := c.Get("userId")
+	application, err := h.ApplicationRepo.FindByID(c.Param("id"))
+	if err != nil || application.UserID != userId.(uuid.UUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return application, false
+	}
+	return application, true
+}
+
+func interviewInvite(interview models.Interview, vacancy models.Vacancy, attendee string) []byte {
+	return utils.BuildICS(utils.CalendarEvent{
+		UID:         fmt.Sprintf("interview-%s@internshiphub", interview.ID),
+		Sequence:    interview.Sequence,
+		Start:       interview.Slot.StartsAt,
+		End:         interview.Slot.EndsAt,
+		Summary:     "Wawancara Magang - " + vacancy.Title,
+		Description: fmt.Sprintf("Wawancara untuk lowongan %s.", vacancy.Title),
+		Location:    interview.Slot.Location,
+		Organizer:   config.AppConfig.SMTPSender,
+		Attendee:    attendee,
+	})
+}
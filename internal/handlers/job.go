@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetJobRuns lists background job executions for central admin
+func (h *Handler) GetJobRuns(c *gin.Context) {
+	name := c.Query("name")
+	status := c.Query("status")
+	pagination := utils.GetPaginationRequest(c)
+
+	runs, total, err := h.JobRunRepo.FindAll(name, status, pagination.Page, pagination.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse{
+		Data: runs,
+		Meta: utils.CreatePaginationMeta(total, pagination.Page, pagination.Limit),
+	})
+}
@@ -0,0 +1,646 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/services"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxLeaveDays bounds a single leave request; longer absences need the unit admin
+const maxLeaveDays = 14
+
+type AssignMentorRequest struct {
+	// MentorID of nil removes the current mentor
+	MentorID *uuid.UUID `json:"mentorId"`
+}
+
+type LogbookEntryRequest struct {
+	// Date defaults to today
+	Date       string `json:"date"`
+	Activities string `json:"activities" binding:"required"`
+}
+
+type LeaveRequestRequest struct {
+	StartDate string `json:"startDate" binding:"required"`
+	EndDate   string `json:"endDate" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+}
+
+type SupervisionDecisionRequest struct {
+	Status models.ApprovalStatus `json:"status" binding:"required,oneof=approved rejected"`
+	Note   string                `json:"note"`
+}
+
+type MentorEvaluationRequest struct {
+	PerformanceScore float64 `json:"performanceScore" binding:"min=0,max=100"`
+	DisciplineScore  float64 `json:"disciplineScore" binding:"min=0,max=100"`
+	Notes            string  `json:"notes"`
+}
+
+// AssignMentor for unit admin
+// @Summary Assign a mentor to an intern
+// @Description Set or remove the mentor supervising an accepted intern. The mentor must hold a role granting mentee.supervise for the vacancy's unit.
+// @Tags Mentorship
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body AssignMentorRequest true "Mentor, or null to remove"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /applications/{id}/mentor [put]
+func (h *Handler) AssignMentor(c *gin.Context) {
+	var req AssignMentorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	application, ok := h.findManagedApplication(c, models.PermissionApplicationReview)
+	if !ok {
+		return
+	}
+	if application.Status != models.ApplicationStatusAccepted && application.Status != models.ApplicationStatusFinished {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only accepted interns can have a mentor"})
+		return
+	}
+
+	if req.MentorID != nil {
+		mentor, err := h.UserRepo.FindByID(req.MentorID.String())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Mentor not found"})
+			return
+		}
+		principal := services.Principal{Role: mentor.Role, UnitKerjaID: mentor.UnitKerjaID}
+		if _, ok := h.Authz.Authorize(principal, models.PermissionMenteeSupervise, &application.Vacancy.UnitKerjaID); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User cannot mentor interns of this unit"})
+			return
+		}
+	}
+
+	if err := h.MentorshipRepo.SetMentor(application.ID, req.MentorID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign mentor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mentor updated", "applicationId": application.ID, "mentorId": req.MentorID})
+}
+
+// GetMentors for unit admin
+// @Summary List the mentors of a unit
+// @Description List the users of a unit whose role lets them supervise interns. Unit admins always get their own unit.
+// @Tags Mentorship
+// @Security BearerAuth
+// @Produce json
+// @Param unitId query string false "Unit kerja ID, required for central admins"
+// @Success 200 {array} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /mentors [get]
+func (h *Handler) GetMentors(c *gin.Context) {
+	scope, _ := h.authorize(c, models.PermissionApplicationReview, nil)
+	unitID := scope
+	if unitID == nil {
+		parsed, err := uuid.Parse(c.Query("unitId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unitId is required"})
+			return
+		}
+		unitID = &parsed
+	}
+
+	roles, err := h.Authz.RolesWith(models.PermissionMenteeSupervise)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentors"})
+		return
+	}
+	mentors, err := h.UserRepo.FindByUnit(*unitID, roles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mentors)
+}
+
+// SaveLogbookEntry for intern
+// @Summary Write a logbook entry
+// @Description Record the day's activities, today unless a date is given. An entry can be rewritten until the mentor approves it; rewriting a rejected entry sends it back for review.
+// @Tags Mentorship
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body LogbookEntryRequest true "Logbook entry"
+// @Success 200 {object} models.LogbookEntry
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /logbook [post]
+func (h *Handler) SaveLogbookEntry(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+	var req LogbookEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date := today()
+	if req.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+			return
+		}
+		if parsed.After(date) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Logbook entries cannot be written ahead"})
+			return
+		}
+		date = parsed
+	}
+
+	app, ok := h.findActiveInternship(c, userID)
+	if !ok {
+		return
+	}
+
+	entry, err := h.MentorshipRepo.FindLogbookEntry(app.ID, date)
+	if err == nil && entry.Status == models.ApprovalStatusApproved {
+		c.JSON(http.StatusConflict, gin.H{"error": "This entry has already been approved"})
+		return
+	}
+	if err != nil {
+		entry = models.LogbookEntry{ApplicationID: app.ID, UserID: userID, Date: date}
+	}
+	entry.Activities = strings.TrimSpace(req.Activities)
+	entry.Status = models.ApprovalStatusPending
+	entry.ReviewedBy = nil
+	entry.ReviewedAt = nil
+	entry.ReviewNote = ""
+
+	if err := h.MentorshipRepo.SaveLogbookEntry(&entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save logbook entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetMyLogbook for intern
+// @Summary Get my logbook
+// @Description Fetch the logbook of the current internship, newest first
+// @Tags Mentorship
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.PaginatedResponse
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /logbook/my [get]
+func (h *Handler) GetMyLogbook(c *gin.Context) {
+	app, ok := h.findActiveInternship(c, c.MustGet("userId").(uuid.UUID))
+	if !ok {
+		return
+	}
+	h.respondLogbook(c, app.ID)
+}
+
+// CreateLeaveRequest for intern
+// @Summary Request leave
+// @Description Ask the mentor to excuse a range of days. Once approved, the working days are recorded as leave in the attendance.
+// @Tags Mentorship
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body LeaveRequestRequest true "Leave request"
+// @Success 201 {object} models.LeaveRequest
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /leave-requests [post]
+func (h *Handler) CreateLeaveRequest(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+	var req LeaveRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	start, errStart := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+	end, errEnd := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+	if errStart != nil || errEnd != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "startDate and endDate must be in YYYY-MM-DD format"})
+		return
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "endDate cannot be before startDate"})
+		return
+	}
+	if end.Sub(start) >= maxLeaveDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Leave cannot be longer than 14 days"})
+		return
+	}
+
+	app, ok := h.findActiveInternship(c, userID)
+	if !ok {
+		return
+	}
+
+	overlaps, err := h.MentorshipRepo.HasOverlappingLeave(app.ID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create leave request"})
+		return
+	}
+	if overlaps {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already requested leave for some of these days"})
+		return
+	}
+
+	leave := models.LeaveRequest{
+		ApplicationID: app.ID,
+		UserID:        userID,
+		StartDate:     start,
+		EndDate:       end,
+		Reason:        strings.TrimSpace(req.Reason),
+		Status:        models.ApprovalStatusPending,
+	}
+	if err := h.MentorshipRepo.CreateLeaveRequest(&leave); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create leave request"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, leave)
+}
+
+// GetMyLeaveRequests for intern
+// @Summary Get my leave requests
+// @Description Fetch the leave requests of the current internship
+// @Tags Mentorship
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.LeaveRequest
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /leave-requests/my [get]
+func (h *Handler) GetMyLeaveRequests(c *gin.Context) {
+	app, ok := h.findActiveInternship(c, c.MustGet("userId").(uuid.UUID))
+	if !ok {
+		return
+	}
+	h.respondLeaveRequests(c, app.ID)
+}
+
+// CancelLeaveRequest for intern
+// @Summary Cancel a leave request
+// @Description Withdraw a leave request the mentor has not decided on yet
+// @Tags Mentorship
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Leave request ID"
+// @Success 200 {object} models.LeaveRequest
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /leave-requests/{id}/cancel [post]
+func (h *Handler) CancelLeaveRequest(c *gin.Context) {
+	userID := c.MustGet("userId").(uuid.UUID)
+	leave, err := h.MentorshipRepo.FindLeaveRequestByID(c.Param("id"))
+	if err != nil || leave.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
+		return
+	}
+	if leave.Status != models.ApprovalStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending leave requests can be cancelled"})
+		return
+	}
+
+	leave.Status = models.ApprovalStatusCancelled
+	if err := h.MentorshipRepo.UpdateLeaveRequest(&leave); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel leave request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, leave)
+}
+
+// GetMentees for mentor
+// @Summary Get my mentees
+// @Description List the interns assigned to the current mentor, ongoing internships first
+// @Tags Mentorship
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Application
+// @Failure 500 {object} map[string]string
+// @Router /mentees [get]
+func (h *Handler) GetMentees(c *gin.Context) {
+	apps, err := h.MentorshipRepo.FindMentees(c.MustGet("userId").(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apps)
+}
+
+// GetMenteeAttendance for mentor
+// @Summary Get a mentee's attendance
+// @Description Fetch the attendance of one of the mentor's interns, newest first
+// @Tags Mentorship
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.PaginatedResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /mentees/{id}/attendance [get]
+func (h *Handler) GetMenteeAttendance(c *gin.Context) {
+	app, ok := h.findMentee(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	h.respondAttendance(c, app.ID)
+}
+
+// GetMenteeLogbook for mentor
+// @Summary Get a mentee's logbook
+// @Description Fetch the logbook of one of the mentor's interns, newest first
+// @Tags Mentorship
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} utils.PaginatedResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /mentees/{id}/logbook [get]
+func (h *Handler) GetMenteeLogbook(c *gin.Context) {
+	app, ok := h.findMentee(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	h.respondLogbook(c, app.ID)
+}
+
+// GetMenteeLeaveRequests for mentor
+// @Summary Get a mentee's leave requests
+// @Description Fetch the leave requests of one of the mentor's interns
+// @Tags Mentorship
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Application ID"
+// @Success 200 {array} models.LeaveRequest
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /mentees/{id}/leave-requests [get]
+func (h *Handler) GetMenteeLeaveRequests(c *gin.Context) {
+	app, ok := h.findMentee(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	h.respondLeaveRequests(c, app.ID)
+}
+
+// ReviewLogbookEntry for mentor
+// @Summary Approve or reject a logbook entry
+// @Tags Mentorship
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Logbook entry ID"
+// @Param request body SupervisionDecisionRequest true "Decision"
+// @Success 200 {object} models.LogbookEntry
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /logbook/{id} [patch]
+func (h *Handler) ReviewLogbookEntry(c *gin.Context) {
+	var req SupervisionDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.MentorshipRepo.FindLogbookEntryByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Logbook entry not found"})
+		return
+	}
+	if _, ok := h.findMentee(c, entry.ApplicationID.String()); !ok {
+		return
+	}
+	if entry.Status != models.ApprovalStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "This entry has already been reviewed"})
+		return
+	}
+
+	mentorID := c.MustGet("userId").(uuid.UUID)
+	now := time.Now()
+	entry.Status = req.Status
+	entry.ReviewedBy = &mentorID
+	entry.ReviewedAt = &now
+	entry.ReviewNote = req.Note
+	if err := h.MentorshipRepo.SaveLogbookEntry(&entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review logbook entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// ReviewLeaveRequest for mentor
+// @Summary Approve or reject a leave request
+// @Description Decide on a pending leave request. Approving it marks its working days as leave in the attendance, except days the intern checked in.
+// @Tags Mentorship
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Leave request ID"
+// @Param request body SupervisionDecisionRequest true "Decision"
+// @Success 200 {object} models.LeaveRequest
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /leave-requests/{id} [patch]
+func (h *Handler) ReviewLeaveRequest(c *gin.Context) {
+	var req SupervisionDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leave, err := h.MentorshipRepo.FindLeaveRequestByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
+		return
+	}
+	if _, ok := h.findMentee(c, leave.ApplicationID.String()); !ok {
+		return
+	}
+	if leave.Status != models.ApprovalStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "This leave request has already been decided"})
+		return
+	}
+
+	mentorID := c.MustGet("userId").(uuid.UUID)
+	now := time.Now()
+	leave.Status = req.Status
+	leave.ReviewedBy = &mentorID
+	leave.ReviewedAt = &now
+	leave.ReviewNote = req.Note
+
+	if req.Status == models.ApprovalStatusApproved {
+		err = h.MentorshipRepo.ApproveLeaveRequest(&leave)
+	} else {
+		err = h.MentorshipRepo.UpdateLeaveRequest(&leave)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review leave request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, leave)
+}
+
+// SubmitMentorEvaluation for mentor
+// @Summary Submit scores for a mentee
+// @Description Give the performance and discipline scores the unit admin starts from when reviewing the internship. They can be changed until the internship is reviewed.
+// @Tags Mentorship
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Application ID"
+// @Param request body MentorEvaluationRequest true "Scores"
+// @Success 200 {object} models.MentorEvaluation
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /mentees/{id}/evaluation [put]
+func (h *Handler) SubmitMentorEvaluation(c *gin.Context) {
+	var req MentorEvaluationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	app, ok := h.findMentee(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if result, err := h.InternshipResultRepo.FindByApplicationID(app.ID); err == nil && result.ReviewedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "The internship has already been reviewed"})
+		return
+	}
+
+	evaluation := models.MentorEvaluation{
+		ApplicationID:    app.ID,
+		MentorID:         c.MustGet("userId").(uuid.UUID),
+		PerformanceScore: req.PerformanceScore,
+		DisciplineScore:  req.DisciplineScore,
+		Notes:            req.Notes,
+	}
+	if err := h.MentorshipRepo.SaveEvaluation(&evaluation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save evaluation"})
+		return
+	}
+
+	saved, err := h.MentorshipRepo.FindEvaluation(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save evaluation"})
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// findMentee loads an internship the current user mentors. It writes the
+// error response when the application is not theirs to supervise.
+func (h *Handler) findMentee(c *gin.Context, appID string) (models.Application, bool) {
+	app, err := h.ApplicationRepo.FindByID(appID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return app, false
+	}
+
+	mentorID := c.MustGet("userId").(uuid.UUID)
+	if _, ok := h.authorize(c, models.PermissionMenteeSupervise, &app.Vacancy.UnitKerjaID); !ok ||
+		app.MentorID == nil || *app.MentorID != mentorID || !isInternship(app.Status) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: you are not this intern's mentor"})
+		return app, false
+	}
+	return app, true
+}
+
+// isInternship reports whether the application went on to an internship
+func isInternship(status models.ApplicationStatus) bool {
+	return status == models.ApplicationStatusAccepted ||
+		status == models.ApplicationStatusFinished ||
+		status == models.ApplicationStatusCompleted
+}
+
+func (h *Handler) respondAttendance(c *gin.Context, appID uuid.UUID) {
+	pagination := utils.GetPaginationRequest(c)
+
+	attendances, total, err := h.AttendanceRepo.FindByApplicationID(appID, pagination.Page, pagination.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse{
+		Data: attendances,
+		Meta: utils.CreatePaginationMeta(total, pagination.Page, pagination.Limit),
+	})
+}
+
+func (h *Handler) respondLogbook(c *gin.Context, appID uuid.UUID) {
+	pagination := utils.GetPaginationRequest(c)
+
+	entries, total, err := h.MentorshipRepo.FindLogbook(appID, pagination.Page, pagination.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch logbook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse{
+		Data: entries,
+		Meta: utils.CreatePaginationMeta(total, pagination.Page, pagination.Limit),
+	})
+}
+
+func (h *Handler) respondLeaveRequests(c *gin.Context, appID uuid.UUID) {
+	leaves, err := h.MentorshipRepo.FindLeaveRequests(appID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leave requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, leaves)
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/services"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// oidcAttemptTTL bounds both the trip to the identity provider and the
+// frontend's exchange of the login code
+const oidcAttemptTTL = 10 * time.Minute
+
+// Reasons a single sign-on is refused, passed to the frontend as ?error=
+var (
+	errSSONoAccess       = errors.New("no_access")
+	errSSOApplicant      = errors.New("applicant_account")
+	errSSOEmailMissing   = errors.New("email_unverified")
+	errSSOAccountDisable = errors.New("account_disabled")
+)
+
+type OIDCExchangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type OIDCRoleMappingRequest struct {
+	Claim       string          `json:"claim" binding:"required"`
+	Value       string          `json:"value" binding:"required"`
+	Role        models.UserRole `json:"role" binding:"required"`
+	UnitKerjaID *uuid.UUID      `json:"unitKerjaId"`
+	Priority    int             `json:"priority"`
+}
+
+// OIDCLogin godoc
+// @Summary Start single sign-on
+// @Description Redirect a unit or central admin to the identity provider. The sign-in uses the authorization code flow with PKCE and comes back through /auth/oidc/callback.
+// @Tags Authentication
+// @Success 302
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /auth/oidc/login [get]
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	if !h.OIDC.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Single sign-on tidak diaktifkan"})
+		return
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start single sign-on"})
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start single sign-on"})
+		return
+	}
+	attempt := models.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		ExpiresAt:    time.Now().Add(oidcAttemptTTL),
+	}
+
+	authURL, err := h.OIDC.AuthURL(c.Request.Context(), attempt.State, attempt.Nonce, attempt.CodeVerifier)
+	if err != nil {
+		log.Printf("Failed to reach identity provider: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider tidak dapat dihubungi"})
+		return
+	}
+	if err := h.OIDCRepo.CreateState(&attempt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start single sign-on"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback godoc
+// @Summary Finish single sign-on
+// @Description Called by the identity provider after sign-in. Verifies the ID token, maps its claims to a role and unit, creates or updates the staff account, and redirects to the frontend with a one-time code for /auth/oidc/exchange, or with an error.
+// @Tags Authentication
+// @Param state query string true "State from /auth/oidc/login"
+// @Param code query string true "Authorization code"
+// @Success 302
+// @Router /auth/oidc/callback [get]
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if !h.OIDC.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Single sign-on tidak diaktifkan"})
+		return
+	}
+	if idpError := c.Query("error"); idpError != "" {
+		h.redirectToFrontend(c, "error", idpError)
+		return
+	}
+
+	attempt, err := h.OIDCRepo.ConsumeState(c.Query("state"))
+	if err != nil {
+		h.redirectToFrontend(c, "error", "invalid_state")
+		return
+	}
+
+	identity, err := h.OIDC.Exchange(c.Request.Context(), c.Query("code"), attempt.CodeVerifier, attempt.Nonce)
+	if err != nil {
+		log.Printf("Single sign-on failed: %v", err)
+		h.redirectToFrontend(c, "error", "invalid_token")
+		return
+	}
+
+	user, err := h.provisionSSOUser(identity)
+	if err != nil {
+		if errors.Is(err, errSSONoAccess) || errors.Is(err, errSSOApplicant) ||
+			errors.Is(err, errSSOEmailMissing) || errors.Is(err, errSSOAccountDisable) {
+			h.redirectToFrontend(c, "error", err.Error())
+			return
+		}
+		log.Printf("Failed to provision single sign-on user %s: %v", identity.Subject, err)
+		h.redirectToFrontend(c, "error", "server_error")
+		return
+	}
+
+	code, hash, err := utils.NewRefreshToken()
+	if err != nil {
+		h.redirectToFrontend(c, "error", "server_error")
+		return
+	}
+	if err := h.OIDCRepo.SetLoginCode(attempt.ID, user.ID, hash); err != nil {
+		h.redirectToFrontend(c, "error", "server_error")
+		return
+	}
+
+	h.redirectToFrontend(c, "code", code)
+}
+
+// OIDCExchange godoc
+// @Summary Exchange a single sign-on code for tokens
+// @Description Trade the one-time code from the single sign-on redirect for an access and refresh token. Accounts that need a second factor get a challenge token for /login/2fa instead, as with a password login.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body OIDCExchangeRequest true "Login code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/oidc/exchange [post]
+func (h *Handler) OIDCExchange(c *gin.Context) {
+	var req OIDCExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := h.OIDCRepo.ExchangeLoginCode(utils.HashRefreshToken(req.Code))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kode login tidak valid atau sudah kedaluwarsa"})
+		return
+	}
+	user, err := h.UserRepo.FindByID(userID.String())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kode login tidak valid atau sudah kedaluwarsa"})
+		return
+	}
+	if user.DeactivatedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akun Anda telah dinonaktifkan. Hubungi administrator."})
+		return
+	}
+	// The second factor is checked here too, whatever the identity provider did
+	if h.twoFactorRequired(user) {
+		respondTwoFactorChallenge(c, user)
+		return
+	}
+
+	h.completeLogin(c, user, nil)
+}
+
+// provisionSSOUser finds the staff account of a signed-in identity, creating
+// it on first sign-in, and applies the role and unit its claims map to. The
+// identity provider decides access: without a matching rule the sign-in is
+// refused even for an existing account.
+func (h *Handler) provisionSSOUser(identity *services.OIDCIdentity) (models.User, error) {
+	mappings, err := h.OIDCRepo.FindMappings()
+	if err != nil {
+		return models.User{}, err
+	}
+	match := services.MatchRoleMapping(identity.Claims, mappings)
+
+	user, err := h.UserRepo.FindByOIDCSubject(identity.Issuer, identity.Subject)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	linked := err == nil
+
+	if !linked {
+		// An existing staff account with the same verified email is linked
+		// instead of duplicated
+		if identity.Email == "" || !identity.EmailVerified {
+			return user, errSSOEmailMissing
+		}
+		user, err = h.UserRepo.FindByEmail(identity.Email)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, err
+		}
+		if err == nil && user.Role.IsExternal() {
+			return user, errSSOApplicant
+		}
+	}
+	if user.Role.IsExternal() && user.ID != uuid.Nil {
+		return user, errSSOApplicant
+	}
+	if match == nil {
+		return user, errSSONoAccess
+	}
+	if user.DeactivatedAt != nil {
+		return user, errSSOAccountDisable
+	}
+
+	issuer, subject := identity.Issuer, identity.Subject
+	user.OIDCIssuer = &issuer
+	user.OIDCSubject = &subject
+	if identity.Name != "" {
+		user.Name = identity.Name
+	}
+
+	if user.ID == uuid.Nil {
+		now := time.Now()
+		user.Email = identity.Email
+		if user.Name == "" {
+			user.Name = identity.Email
+		}
+		user.Role = match.Role
+		user.UnitKerjaID = match.UnitKerjaID
+		user.EmailVerifiedAt = &now
+		if err := h.UserRepo.Create(&user); err != nil {
+			return user, err
+		}
+		return h.UserRepo.FindByID(user.ID.String())
+	}
+
+	// A changed role or unit must not live on in tokens issued before it
+	changed := user.Role != match.Role || !sameUnit(user.UnitKerjaID, match.UnitKerjaID)
+	user.Role = match.Role
+	user.UnitKerjaID = match.UnitKerjaID
+	user.UnitKerja = nil
+	if changed {
+		markTokensRevoked(&user)
+	}
+	if err := h.UserRepo.Update(&user); err != nil {
+		return user, err
+	}
+	if changed {
+		if err := h.SessionRepo.RevokeAllForUser(user.ID, nil); err != nil {
+			return user, err
+		}
+	}
+	return h.UserRepo.FindByID(user.ID.String())
+}
+
+func (h *Handler) redirectToFrontend(c *gin.Context, key, value string) {
+	target, err := url.Parse(h.OIDC.FrontendURL())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid OIDC_FRONTEND_URL"})
+		return
+	}
+	query := target.Query()
+	query.Set(key, value)
+	target.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, target.String())
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func sameUnit(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// GetOIDCRoleMappings lists the single sign-on role rules in the order they are tried
+func (h *Handler) GetOIDCRoleMappings(c *gin.Context) {
+	mappings, err := h.OIDCRepo.FindMappings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch role mappings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mappings)
+}
+
+// CreateOIDCRoleMapping for superadmin
+func (h *Handler) CreateOIDCRoleMapping(c *gin.Context) {
+	var req OIDCRoleMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.validRoleMapping(c, req) {
+		return
+	}
+
+	mapping := models.OIDCRoleMapping{
+		Claim:       req.Claim,
+		Value:       req.Value,
+		Role:        req.Role,
+		UnitKerjaID: req.UnitKerjaID,
+		Priority:    req.Priority,
+	}
+	if err := h.OIDCRepo.CreateMapping(&mapping); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create role mapping"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, mapping)
+}
+
+// UpdateOIDCRoleMapping for superadmin
+func (h *Handler) UpdateOIDCRoleMapping(c *gin.Context) {
+	var req OIDCRoleMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mapping, err := h.OIDCRepo.FindMappingByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role mapping not found"})
+		return
+	}
+	if !h.validRoleMapping(c, req) {
+		return
+	}
+
+	mapping.Claim = req.Claim
+	mapping.Value = req.Value
+	mapping.Role = req.Role
+	mapping.UnitKerjaID = req.UnitKerjaID
+	mapping.UnitKerja = nil
+	mapping.Priority = req.Priority
+	if err := h.OIDCRepo.UpdateMapping(&mapping); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role mapping"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapping)
+}
+
+// DeleteOIDCRoleMapping for superadmin
+func (h *Handler) DeleteOIDCRoleMapping(c *gin.Context) {
+	if _, err := h.OIDCRepo.FindMappingByID(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role mapping not found"})
+		return
+	}
+	if err := h.OIDCRepo.DeleteMapping(c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role mapping"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role mapping deleted successfully"})
+}
+
+// validRoleMapping checks that a rule grants an existing staff role, with a
+// unit exactly when the role is limited to one
+func (h *Handler) validRoleMapping(c *gin.Context, req OIDCRoleMappingRequest) bool {
+	if req.Role.IsExternal() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Single sign-on cannot grant the " + string(req.Role) + " role"})
+		return false
+	}
+	role, ok := h.validUserRole(c, req.Role, req.UnitKerjaID)
+	if !ok {
+		return false
+	}
+	if !role.UnitScoped && req.UnitKerjaID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unitKerjaId must be empty for role " + string(req.Role)})
+		return false
+	}
+	return true
+}
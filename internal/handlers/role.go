@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/dr15/internship-hub-api/internal/middleware"
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+type RoleRequest struct {
+	// Name is only read when creating a role; users refer to it, so it cannot change
+	Name             models.UserRole     `json:"name"`
+	Description      string              `json:"description"`
+	Permissions      []models.Permission `json:"permissions" binding:"required"`
+	UnitScoped       bool                `json:"unitScoped"`
+	RequireTwoFactor bool                `json:"requireTwoFactor"`
+}
+
+// GetPermissions lists every permission a role can grant
+func (h *Handler) GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, models.Permissions)
+}
+
+// GetRoles for superadmin
+func (h *Handler) GetRoles(c *gin.Context) {
+	roles, err := h.RoleRepo.FindAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole for superadmin
+func (h *Handler) CreateRole(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !roleNamePattern.MatchString(string(req.Name)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 2-32 lowercase letters, digits, '-' or '_' and start with a letter"})
+		return
+	}
+	permissions, ok := validRolePermissions(c, req)
+	if !ok {
+		return
+	}
+	if _, err := h.RoleRepo.FindByName(req.Name); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Role already exists"})
+		return
+	}
+
+	role := models.Role{
+		Name:             req.Name,
+		Description:      req.Description,
+		Permissions:      permissions,
+		UnitScoped:       req.UnitScoped,
+		RequireTwoFactor: req.RequireTwoFactor,
+	}
+	if err := h.RoleRepo.Create(&role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create role"})
+		return
+	}
+	h.Authz.Invalidate()
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole for superadmin. Changes apply to the role's users right away.
+func (h *Handler) UpdateRole(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.RoleRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+	permissions, ok := validRolePermissions(c, req)
+	if !ok {
+		return
+	}
+
+	// Admins must not lock themselves out of role management
+	if middleware.CurrentPrincipal(c).Role == role.Name && !hasPermission(permissions, models.PermissionRoleManage) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove role.manage from your own role"})
+		return
+	}
+
+	role.Description = req.Description
+	role.Permissions = permissions
+	role.UnitScoped = req.UnitScoped
+	role.RequireTwoFactor = req.RequireTwoFactor
+	if err := h.RoleRepo.Update(&role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	h.Authz.Invalidate()
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole for superadmin. Built-in roles and roles still in use stay.
+func (h *Handler) DeleteRole(c *gin.Context) {
+	role, err := h.RoleRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+	if role.System {
+		c.JSON(http.StatusConflict, gin.H{"error": "Built-in roles cannot be deleted"})
+		return
+	}
+
+	users, err := h.RoleRepo.CountUsers(role.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+	if users > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Role is still assigned to users", "users": users})
+		return
+	}
+	mappings, err := h.OIDCRepo.FindMappings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+	for _, mapping := range mappings {
+		if mapping.Role == role.Name {
+			c.JSON(http.StatusConflict, gin.H{"error": "Role is still used by a single sign-on role mapping"})
+			return
+		}
+	}
+
+	if err := h.RoleRepo.Delete(role.ID.String()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+	h.Authz.Invalidate()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// validRolePermissions checks that every permission exists and suits the
+// role's scope, and returns them without duplicates
+func validRolePermissions(c *gin.Context, req RoleRequest) (pq.StringArray, bool) {
+	permissions := pq.StringArray{}
+	for _, name := range req.Permissions {
+		info, ok := models.FindPermission(name)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown permission: " + string(name)})
+			return nil, false
+		}
+		if req.UnitScoped && !info.UnitScopable {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Permission " + string(name) + " cannot be limited to one unit"})
+			return nil, false
+		}
+		if !hasPermission(permissions, name) {
+			permissions = append(permissions, string(name))
+		}
+	}
+	return permissions, true
+}
+
+func hasPermission(permissions pq.StringArray, permission models.Permission) bool {
+	for _, p := range permissions {
+		if models.Permission(p) == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// validUserRole checks that a role exists and that users of a unit scoped
+// role belong to a unit. It writes the error response and returns the role.
+func (h *Handler) validUserRole(c *gin.Context, name models.UserRole, unitID *uuid.UUID) (models.Role, bool) {
+	role, ok := h.Authz.Role(name)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role: " + string(name)})
+		return role, false
+	}
+	if role.UnitScoped && unitID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unitKerjaId is required for role " + string(name)})
+		return role, false
+	}
+	if unitID != nil {
+		if _, err := h.UnitKerjaRepo.FindByID(unitID.String()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unit kerja not found"})
+			return role, false
+		}
+	}
+	return role, true
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type LogoutRequest struct {
+	// All ends every session of the user instead of only the current one
+	All bool `json:"all"`
+}
+
+// TokenResponse is the token pair handed out on login and refresh
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+// RefreshToken godoc
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once; presenting a used one again ends the whole session it belongs to.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /token/refresh [post]
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refreshToken, hash, err := utils.NewRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	next := models.RefreshToken{TokenHash: hash, ExpiresAt: time.Now().Add(utils.RefreshTokenTTL())}
+
+	current, err := h.TokenRepo.Rotate(utils.HashRefreshToken(req.RefreshToken), &next)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token sudah pernah dipakai. Sesi ini telah diakhiri, silakan login kembali."})
+		case errors.Is(err, repository.ErrTokenInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	user, err := h.UserRepo.FindByID(current.UserID.String())
+	if err != nil || user.DeactivatedAt != nil {
+		h.SessionRepo.Revoke(current.FamilyID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	token, err := utils.GenerateToken(user, current.FamilyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+	})
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description End the current session so its access and refresh tokens stop working. With "all" set, every session of the user is ended.
+// @Tags Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body LogoutRequest false "Logout options"
+// @Success 200 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /logout [post]
+func (h *Handler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	// The body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userId, _ := c.Get("userId")
+	sessionId, _ := c.Get("sessionId")
+
+	var err error
+	if req.All {
+		err = h.revokeUserTokens(userId.(uuid.UUID))
+	} else {
+		err = h.SessionRepo.Revoke(sessionId.(uuid.UUID))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logout berhasil"})
+}
+
+// startSession signs the user in on the requesting device: it records a new
+// session and returns the first token pair of its refresh token family
+func (h *Handler) startSession(c *gin.Context, user models.User) (TokenResponse, error) {
+	refreshToken, hash, err := utils.NewRefreshToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(utils.RefreshTokenTTL())
+	session := models.Session{
+		UserID:       user.ID,
+		UserAgent:    c.Request.UserAgent(),
+		IPAddress:    c.ClientIP(),
+		LastActiveAt: now,
+		ExpiresAt:    expiresAt,
+	}
+	if err := h.SessionRepo.Create(&session, &models.RefreshToken{TokenHash: hash, ExpiresAt: expiresAt}); err != nil {
+		return TokenResponse{}, err
+	}
+
+	token, err := utils.GenerateToken(user, session.ID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	return TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// revokeUserTokens ends every session of the user and invalidates access
+// tokens issued so far
+func (h *Handler) revokeUserTokens(userID uuid.UUID) error {
+	if err := h.SessionRepo.RevokeAllForUser(userID, nil); err != nil {
+		return err
+	}
+	user, err := h.UserRepo.FindByID(userID.String())
+	if err != nil {
+		return err
+	}
+	markTokensRevoked(&user)
+	return h.UserRepo.Update(&user)
+}
+
+// markTokensRevoked moves the user's token cut-off to now. Tokens carry
+// whole seconds, so the cut-off is truncated to let tokens issued in the
+// same second afterwards through.
+func markTokensRevoked(user *models.User) {
+	now := time.Now().Truncate(time.Second)
+	user.TokensValidAfter = &now
+}
+
+// GetMySessions godoc
+// @Summary List my active sessions
+// @Description List the devices where the current user is signed in, with user agent, IP address and last activity. The session making the request is marked as current.
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Session
+// @Failure 500 {object} map[string]string
+// @Router /me/sessions [get]
+func (h *Handler) GetMySessions(c *gin.Context) {
+	userId, _ := c.Get("userId")
+	sessionId, _ := c.Get("sessionId")
+
+	sessions, err := h.SessionRepo.FindActiveByUser(userId.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == sessionId.(uuid.UUID)
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeMySession godoc
+// @Summary End one of my sessions
+// @Description Sign out the given session. Its access and refresh tokens stop working immediately.
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /me/sessions/{id} [delete]
+func (h *Handler) RevokeMySession(c *gin.Context) {
+	userId, _ := c.Get("userId")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	session, err := h.SessionRepo.FindByID(id)
+	if err != nil || session.UserID != userId.(uuid.UUID) || session.RevokedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := h.SessionRepo.Revoke(session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengakhiri sesi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sesi berhasil diakhiri"})
+}
+
+// RevokeMyOtherSessions godoc
+// @Summary End my other sessions
+// @Description Sign out every session of the current user except the one making the request. Use POST /logout with "all" to end the current one too.
+// @Tags Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /me/sessions [delete]
+func (h *Handler) RevokeMyOtherSessions(c *gin.Context) {
+	userId, _ := c.Get("userId")
+	sessionId, _ := c.Get("sessionId")
+	current := sessionId.(uuid.UUID)
+
+	if err := h.SessionRepo.RevokeAllForUser(userId.(uuid.UUID), &current); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengakhiri sesi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Semua sesi lain berhasil diakhiri"})
+}
+
+// GetUserSessions lists the active sessions of any user, for central admins
+func (h *Handler) GetUserSessions(c *gin.Context) {
+	user, err := h.UserRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	sessions, err := h.SessionRepo.FindActiveByUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// ForceLogoutUser ends every session of a user and invalidates their access
+// tokens, for central admins
+func (h *Handler) ForceLogoutUser(c *gin.Context) {
+	user, err := h.UserRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.revokeUserTokens(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end user sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions of the user have been ended"})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// throttled answers 429 when attempts on any of the keys are being held back
+// and reports whether it did
+func (h *Handler) throttled(c *gin.Context, keys ...string) bool {
+	wait, locked, err := h.Throttle.Wait(keys...)
+	if err != nil {
+		// Failing open keeps sign-in available if the counters cannot be read
+		log.Printf("Failed to check auth throttle: %v", err)
+		return false
+	}
+	if wait <= 0 {
+		return false
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	message := "Terlalu banyak percobaan. Silakan coba lagi dalam " + strconv.Itoa(seconds) + " detik."
+	if locked {
+		message = "Terlalu banyak percobaan gagal. Akun atau alamat IP Anda dikunci sementara, silakan coba lagi nanti."
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": message, "retryAfter": seconds})
+	return true
+}
+
+// recordFailure counts a failed attempt for the account and the IP address.
+// When it locks the account of an existing user, the user is told by email.
+func (h *Handler) recordFailure(accountKey, ipKey string, user *models.User) {
+	until, err := h.Throttle.Fail(accountKey, services.AccountPolicy())
+	if err != nil {
+		log.Printf("Failed to record failed attempt for %s: %v", accountKey, err)
+	}
+	if until != nil && user != nil {
+		go h.Notifier.AccountLocked(*user, *until)
+	}
+	if _, err := h.Throttle.Fail(ipKey, services.IPPolicy()); err != nil {
+		log.Printf("Failed to record failed attempt for %s: %v", ipKey, err)
+	}
+}
+
+// UnlockUser clears the failed login and password reset counters of a user,
+// ending a lockout, for central admins
+func (h *Handler) UnlockUser(c *gin.Context) {
+	user, err := h.UserRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.Throttle.Succeed(services.AccountKey("login", user.Email), services.AccountKey("forgot", user.Email)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
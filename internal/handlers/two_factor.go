@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/services"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	// Either the current authenticator code or one of the recovery codes
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type TwoFactorEnrollRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// twoFactorRequired reports whether signing in needs a second factor. Roles
+// such as central admin make it mandatory; other users may opt in.
+func (h *Handler) twoFactorRequired(user models.User) bool {
+	return user.TOTPEnabledAt != nil || h.Authz.RequiresTwoFactor(user.Role)
+}
+
+// respondTwoFactorChallenge answers a first login step with a challenge token
+// for /login/2fa instead of the session tokens
+func respondTwoFactorChallenge(c *gin.Context, user models.User) {
+	enroll := user.TOTPEnabledAt == nil
+	challenge, err := utils.GenerateChallengeToken(user, enroll)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Two-factor authentication required",
+		"mfaRequired":        true,
+		"enrollmentRequired": enroll,
+		"challengeToken":     challenge,
+	})
+}
+
+// GetTwoFactorStatus godoc
+// @Summary Get my two-factor status
+// @Description Whether two-factor authentication is enabled or required for the current user and how many recovery codes are left
+// @Tags Two-Factor Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /me/2fa [get]
+func (h *Handler) GetTwoFactorStatus(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var remaining int64
+	if user.TOTPEnabledAt != nil {
+		count, err := h.TwoFactorRepo.CountRecoveryCodes(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch two-factor status"})
+			return
+		}
+		remaining = count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":             user.TOTPEnabledAt != nil,
+		"enabledAt":           user.TOTPEnabledAt,
+		"required":            h.Authz.RequiresTwoFactor(user.Role),
+		"recoveryCodesLeft":   remaining,
+		"recoveryCodesIssued": utils.RecoveryCodeCount,
+	})
+}
+
+// SetupTwoFactor godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a new TOTP secret for the current user and return it with an otpauth URL and QR code for an authenticator app. Enrollment completes once a code is confirmed.
+// @Tags Two-Factor Authentication
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.TOTPSetup
+// @Failure 409 {object} map[string]string
+// @Router /me/2fa/setup [post]
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	h.startTwoFactorSetup(c, user)
+}
+
+// EnableTwoFactor godoc
+// @Summary Confirm two-factor enrollment
+// @Description Confirm the secret from setup with a code from the authenticator app. Returns one-time recovery codes, shown only this once.
+// @Tags Two-Factor Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorCodeRequest true "Authenticator code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /me/2fa/enable [post]
+func (h *Handler) EnableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	codes, ok := h.enableTwoFactor(c, user, req.Code)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Autentikasi dua faktor berhasil diaktifkan. Simpan kode pemulihan di tempat yang aman.",
+		"recoveryCodes": codes,
+	})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication for the current user after confirming the password and a current code. Central admins cannot turn it off.
+// @Tags Two-Factor Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body DisableTwoFactorRequest true "Password and authenticator code"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /me/2fa/disable [post]
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if h.Authz.RequiresTwoFactor(user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Autentikasi dua faktor wajib untuk peran Anda"})
+		return
+	}
+	if user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Autentikasi dua faktor belum aktif"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password salah"})
+		return
+	}
+	if !h.checkSecondFactor(c, user, req.Code, "") {
+		return
+	}
+
+	if err := h.TwoFactorRepo.Disable(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menonaktifkan autentikasi dua faktor"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Autentikasi dua faktor telah dinonaktifkan"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes of the current user with a new set after confirming a current code
+// @Tags Two-Factor Authentication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorCodeRequest true "Authenticator code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /me/2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Autentikasi dua faktor belum aktif"})
+		return
+	}
+	if !h.checkSecondFactor(c, user, req.Code, "") {
+		return
+	}
+
+	codes, hashes, err := utils.NewRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat kode pemulihan"})
+		return
+	}
+	if err := h.TwoFactorRepo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat kode pemulihan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// LoginTwoFactor godoc
+// @Summary Complete login with a second factor
+// @Description Second login step for accounts with two-factor authentication. Takes the challenge token from /login and an authenticator code or a recovery code, and returns the access and refresh tokens.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /login/2fa [post]
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, claims, ok := h.challengeUser(c, req.ChallengeToken)
+	if !ok {
+		return
+	}
+	if claims.Enroll || user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Autentikasi dua faktor harus diatur terlebih dahulu"})
+		return
+	}
+
+	if !h.checkSecondFactor(c, user, req.Code, req.RecoveryCode) {
+		return
+	}
+	h.completeLogin(c, user, nil)
+}
+
+// SetupTwoFactorEnrollment godoc
+// @Summary Start required two-factor enrollment during login
+// @Description For accounts that must use two-factor authentication but have not set it up. Takes the challenge token from /login and returns a new TOTP secret with its QR code.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorChallengeRequest true "Challenge token"
+// @Success 200 {object} utils.TOTPSetup
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /login/2fa/setup [post]
+func (h *Handler) SetupTwoFactorEnrollment(c *gin.Context) {
+	var req TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, claims, ok := h.challengeUser(c, req.ChallengeToken)
+	if !ok {
+		return
+	}
+	if !claims.Enroll {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge token is not for enrollment"})
+		return
+	}
+	h.startTwoFactorSetup(c, user)
+}
+
+// EnrollTwoFactor godoc
+// @Summary Finish required two-factor enrollment and log in
+// @Description Confirm the secret from /login/2fa/setup with an authenticator code. Enables two-factor authentication and returns the tokens together with one-time recovery codes.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorEnrollRequest true "Challenge token and code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /login/2fa/enroll [post]
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	var req TwoFactorEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, claims, ok := h.challengeUser(c, req.ChallengeToken)
+	if !ok {
+		return
+	}
+	if !claims.Enroll {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge token is not for enrollment"})
+		return
+	}
+
+	codes, ok := h.enableTwoFactor(c, user, req.Code)
+	if !ok {
+		return
+	}
+	h.completeLogin(c, user, gin.H{"recoveryCodes": codes})
+}
+
+// ResetUserTwoFactor removes two-factor authentication from a user who lost
+// both their authenticator and recovery codes, for central admins. Central
+// admins are asked to enroll again at their next login.
+func (h *Handler) ResetUserTwoFactor(c *gin.Context) {
+	user, err := h.UserRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.TwoFactorRepo.Disable(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication has been reset"})
+}
+
+// currentUser loads the signed-in user
+func (h *Handler) currentUser(c *gin.Context) (models.User, bool) {
+	userId, _ := c.Get("userId")
+	user, err := h.UserRepo.FindByID(userId.(uuid.UUID).String())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return user, false
+	}
+	return user, true
+}
+
+// challengeUser loads the user a login challenge token was issued to
+func (h *Handler) challengeUser(c *gin.Context, token string) (models.User, *utils.ChallengeClaims, bool) {
+	claims, err := utils.ValidateChallengeToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sesi login sudah kedaluwarsa, silakan login kembali"})
+		return models.User{}, nil, false
+	}
+	user, err := h.UserRepo.FindByID(claims.UserID.String())
+	if err != nil || user.DeactivatedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sesi login sudah kedaluwarsa, silakan login kembali"})
+		return user, nil, false
+	}
+	return user, claims, true
+}
+
+func (h *Handler) startTwoFactorSetup(c *gin.Context, user models.User) {
+	if user.TOTPEnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Autentikasi dua faktor sudah aktif"})
+		return
+	}
+
+	setup, err := utils.NewTOTPSetup(user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat kunci autentikasi"})
+		return
+	}
+	if err := h.TwoFactorRepo.SavePendingSecret(user.ID, setup.Secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat kunci autentikasi"})
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// enableTwoFactor confirms the pending secret with a code and returns the new
+// recovery codes
+func (h *Handler) enableTwoFactor(c *gin.Context, user models.User, code string) ([]string, bool) {
+	if user.TOTPEnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Autentikasi dua faktor sudah aktif"})
+		return nil, false
+	}
+	if user.TOTPPendingSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mulai pengaturan autentikasi dua faktor terlebih dahulu"})
+		return nil, false
+	}
+	counter, ok := utils.MatchTOTP(code, user.TOTPPendingSecret, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode autentikasi salah"})
+		return nil, false
+	}
+
+	codes, hashes, err := utils.NewRecoveryCodes()
+	if err == nil {
+		err = h.TwoFactorRepo.Enable(user.ID, counter, hashes)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengaktifkan autentikasi dua faktor"})
+		return nil, false
+	}
+	return codes, true
+}
+
+// checkSecondFactor verifies an authenticator code, or a recovery code when
+// one is given, answering 401 or 429 when it fails. Attempts are throttled
+// like passwords so the six digit codes cannot be guessed.
+func (h *Handler) checkSecondFactor(c *gin.Context, user models.User, code, recoveryCode string) bool {
+	accountKey := services.AccountKey("2fa", user.ID.String())
+	ipKey := services.IPKey("2fa", c.ClientIP())
+	if h.throttled(c, accountKey, ipKey) {
+		return false
+	}
+
+	var ok bool
+	var err error
+	if recoveryCode != "" {
+		ok, err = h.TwoFactorRepo.ConsumeRecoveryCode(user.ID, utils.HashRecoveryCode(recoveryCode))
+	} else if counter, matched := utils.MatchTOTP(code, user.TOTPSecret, time.Now()); matched {
+		ok, err = h.TwoFactorRepo.AdvanceCounter(user.ID, counter)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memverifikasi kode"})
+		return false
+	}
+	if !ok {
+		h.recordFailure(accountKey, ipKey, &user)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kode autentikasi salah atau sudah digunakan"})
+		return false
+	}
+
+	if err := h.Throttle.Succeed(accountKey); err != nil {
+		log.Printf("Failed to reset two-factor throttle for %s: %v", accountKey, err)
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/storage"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// fieldUploadError ties an upload error to the form field it came from
+type fieldUploadError struct {
+	Field string
+	Err   error
+}
+
+func (e *fieldUploadError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *fieldUploadError) Unwrap() error {
+	return e.Err
+}
+
+// readUpload validates the file uploaded in the given form field
+func readUpload(file *multipart.FileHeader, field string, policy utils.UploadPolicy) (*utils.Upload, error) {
+	upload, err := utils.ReadUpload(file, policy)
+	if err != nil {
+		return nil, &fieldUploadError{Field: field, Err: err}
+	}
+	return upload, nil
+}
+
+// respondUploadError answers with the status and message of a refused upload,
+// or reports whether err was not an upload error.
+func respondUploadError(c *gin.Context, err error) bool {
+	var uploadErr *utils.UploadError
+	if !errors.As(err, &uploadErr) {
+		return false
+	}
+	body := gin.H{"error": err.Error()}
+	var fieldErr *fieldUploadError
+	if errors.As(err, &fieldErr) {
+		body["field"] = fieldErr.Field
+	}
+	c.JSON(uploadErr.Status, body)
+	return true
+}
+
+// storeUpload writes a validated upload to <dir> in the file storage, named
+// after its content hash, and returns the file name. Content already stored
+// in the directory is not written again.
+func (h *Handler) storeUpload(upload *utils.Upload, dir string) (string, error) {
+	name := upload.Hash + upload.Extension
+	key := storage.Key(dir, name)
+
+	ctx := context.Background()
+	exists, err := h.Storage.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if err := h.Storage.Put(ctx, key, bytes.NewReader(upload.Data), upload.Size(), upload.ContentType); err != nil {
+			return "", err
+		}
+	}
+
+	return name, h.FileRepo.Register(&models.StoredFile{
+		Dir:         dir,
+		Hash:        upload.Hash,
+		FileName:    name,
+		ContentType: upload.ContentType,
+		Size:        upload.Size(),
+	})
+}
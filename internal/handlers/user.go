@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/dr15/internship-hub-api/internal/models"
 	"github.com/dr15/internship-hub-api/internal/utils"
@@ -22,6 +23,7 @@ type UpdateUserRequest struct {
 	Name        string          `json:"name"`
 	Role        models.UserRole `json:"role"`
 	UnitKerjaID *uuid.UUID      `json:"unitKerjaId"`
+	Active      *bool           `json:"active"`
 }
 
 // GetUsers for superadmin
@@ -36,6 +38,20 @@ func (h *Handler) GetUsers(c *gin.Context) {
 		return
 	}
 
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	counts, err := h.SessionRepo.CountActiveByUsers(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	for i := range users {
+		count := counts[users[i].ID]
+		users[i].ActiveSessions = &count
+	}
+
 	c.JSON(http.StatusOK, utils.PaginatedResponse{
 		Data: users,
 		Meta: utils.CreatePaginationMeta(total, pagination.Page, pagination.Limit),
@@ -49,6 +65,9 @@ func (h *Handler) CreateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if _, ok := h.validUserRole(c, req.Role, req.UnitKerjaID); !ok {
+		return
+	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -63,6 +82,9 @@ func (h *Handler) CreateUser(c *gin.Context) {
 		Role:        req.Role,
 		UnitKerjaID: req.UnitKerjaID,
 	}
+	// Accounts created by an admin do not go through email verification
+	now := time.Now()
+	user.EmailVerifiedAt = &now
 
 	if err := h.UserRepo.Create(&user); err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
@@ -87,6 +109,7 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	previousRole, previousUnit := user.Role, user.UnitKerjaID
 	if req.Name != "" {
 		user.Name = req.Name
 	}
@@ -94,11 +117,37 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		user.Role = req.Role
 	}
 	user.UnitKerjaID = req.UnitKerjaID
+	user.UnitKerja = nil
+	if _, ok := h.validUserRole(c, user.Role, user.UnitKerjaID); !ok {
+		return
+	}
+
+	// Deactivating, or changing the role or unit, ends all of the user's
+	// sessions at once
+	deactivated := req.Active != nil && !*req.Active && user.DeactivatedAt == nil
+	if req.Active != nil {
+		if *req.Active {
+			user.DeactivatedAt = nil
+		} else if deactivated {
+			now := time.Now()
+			user.DeactivatedAt = &now
+		}
+	}
+	revoke := deactivated || user.Role != previousRole || !sameUnit(user.UnitKerjaID, previousUnit)
+	if revoke {
+		markTokensRevoked(&user)
+	}
 
 	if err := h.UserRepo.Update(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
+	if revoke {
+		if err := h.SessionRepo.RevokeAllForUser(user.ID, nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end user sessions"})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, user)
 }
@@ -106,10 +155,19 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 // DeleteUser for superadmin
 func (h *Handler) DeleteUser(c *gin.Context) {
 	id := c.Param("id")
+	user, err := h.UserRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
 	if err := h.UserRepo.Delete(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
+	if err := h.SessionRepo.RevokeAllForUser(user.ID, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end user sessions"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
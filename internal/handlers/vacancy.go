@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
 	"github.com/dr15/internship-hub-api/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -12,25 +17,69 @@ import (
 )
 
 type VacancyRequest struct {
-	Title          string         `json:"title" binding:"required"`
-	UnitKerjaID    uuid.UUID      `json:"unitKerjaId" binding:"required"`
-	Description    string         `json:"description" binding:"required"`
-	Requirements   pq.StringArray `json:"requirements" binding:"required"`
-	Duration       string         `json:"duration" binding:"required"`
-	DurationMonths int            `json:"durationMonths" binding:"required"`
-	Location       string         `json:"location" binding:"required"`
-	Quota          int            `json:"quota" binding:"required"`
-	Deadline       string         `json:"deadline" binding:"required"`
+	Title             string         `json:"title" binding:"required"`
+	UnitKerjaID       uuid.UUID      `json:"unitKerjaId" binding:"required"`
+	Description       string         `json:"description" binding:"required"`
+	Requirements      pq.StringArray `json:"requirements" binding:"required"`
+	RequiredDocuments pq.StringArray `json:"requiredDocuments" binding:"dive,oneof=transcript recommendation_letter id_card"`
+	Duration          string         `json:"duration" binding:"required"`
+	DurationMonths    int            `json:"durationMonths" binding:"required"`
+	Location          string         `json:"location" binding:"required"`
+	Quota             int            `json:"quota" binding:"required"`
+	Deadline          string         `json:"deadline" binding:"required"`
+	// Questions are only read on creation; use PUT /vacancies/{id}/questions afterwards
+	Questions []VacancyQuestionRequest `json:"questions" binding:"dive"`
+}
+
+// VacancyDraftRequest is the relaxed form of VacancyRequest used while a
+// vacancy is still a draft. Completeness is checked on submission instead.
+type VacancyDraftRequest struct {
+	Title             string         `json:"title" binding:"required"`
+	UnitKerjaID       uuid.UUID      `json:"unitKerjaId" binding:"required"`
+	Description       string         `json:"description"`
+	Requirements      pq.StringArray `json:"requirements"`
+	RequiredDocuments pq.StringArray `json:"requiredDocuments" binding:"dive,oneof=transcript recommendation_letter id_card"`
+	Duration          string         `json:"duration"`
+	DurationMonths    int            `json:"durationMonths"`
+	Location          string         `json:"location"`
+	Quota             int            `json:"quota"`
+	Deadline          string         `json:"deadline"`
+	// Questions are only read on creation; use PUT /vacancies/{id}/questions afterwards
+	Questions []VacancyQuestionRequest `json:"questions" binding:"dive"`
+}
+
+func (r VacancyDraftRequest) apply(vacancy *models.Vacancy) error {
+	var deadline time.Time
+	if r.Deadline != "" {
+		parsed, err := time.Parse("2006-01-02", r.Deadline)
+		if err != nil {
+			return errors.New("Invalid deadline format, use YYYY-MM-DD")
+		}
+		deadline = parsed
+	}
+
+	vacancy.Title = r.Title
+	vacancy.UnitKerjaID = r.UnitKerjaID
+	vacancy.Description = r.Description
+	vacancy.Requirements = r.Requirements
+	vacancy.RequiredDocuments = r.RequiredDocuments
+	vacancy.Duration = r.Duration
+	vacancy.DurationMonths = r.DurationMonths
+	vacancy.Location = r.Location
+	vacancy.Quota = r.Quota
+	vacancy.Deadline = deadline
+	return nil
 }
 
 type ApprovalRequest struct {
-	Status        models.VacancyStatus `json:"status" binding:"required"`
-	RejectionNote string               `json:"rejectionNote"`
+	Status models.VacancyStatus `json:"status" binding:"required,oneof=approved rejected"`
+	// RejectionNote tells the unit what to fix and is required when rejecting
+	RejectionNote string `json:"rejectionNote"`
 }
 
 // GetVacancies returns all approved vacancies for public/applicants
 // @Summary List approved vacancies
-// @Description Fetch all vacancies with 'approved' status. Can be filtered by unitId.
+// @Description Fetch all vacancies with 'approved' status, including applicant counts and remaining seats. Can be filtered by unitId.
 // @Tags Vacancies
 // @Produce json
 // @Param unitId query string false "Filter by Unit Kerja ID"
@@ -56,7 +105,7 @@ func (h *Handler) GetVacancies(c *gin.Context) {
 
 // GetVacancy returns single vacancy detail
 // @Summary Get vacancy detail
-// @Description Fetch detailed information about a specific vacancy
+// @Description Fetch detailed information about a specific vacancy, including applicant counts and remaining seats. Vacancies that are not published are only shown to admins who manage or approve them.
 // @Tags Vacancies
 // @Produce json
 // @Param id path string true "Vacancy ID"
@@ -66,7 +115,7 @@ func (h *Handler) GetVacancies(c *gin.Context) {
 func (h *Handler) GetVacancy(c *gin.Context) {
 	id := c.Param("id")
 	vacancy, err := h.VacancyRepo.FindByID(id)
-	if err != nil {
+	if err != nil || !h.canSeeVacancy(c, vacancy) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
 		return
 	}
@@ -74,9 +123,23 @@ func (h *Handler) GetVacancy(c *gin.Context) {
 	c.JSON(http.StatusOK, vacancy)
 }
 
+// canSeeVacancy reports whether the caller may read the vacancy. Published
+// ones are public; drafts, submissions and rejections stay with the admins
+// of the unit and the approvers.
+func (h *Handler) canSeeVacancy(c *gin.Context, vacancy models.Vacancy) bool {
+	if vacancy.Status == models.VacancyStatusApproved || vacancy.Status == models.VacancyStatusClosed {
+		return true
+	}
+	if _, ok := h.authorize(c, models.PermissionVacancyManage, &vacancy.UnitKerjaID); ok {
+		return true
+	}
+	_, ok := h.authorize(c, models.PermissionVacancyApprove, &vacancy.UnitKerjaID)
+	return ok
+}
+
 // CreateVacancy for unit admin
 // @Summary Create a new vacancy
-// @Description Create a new internship vacancy (for Unit Admins). Initial status will be 'pending'.
+// @Description Create a new internship vacancy (for Unit Admins). Initial status will be 'pending'. Extra questions for applicants (text, choice, number or file) can be defined in 'questions'.
 // @Tags Vacancies
 // @Security BearerAuth
 // @Accept json
@@ -95,11 +158,9 @@ func (h *Handler) CreateVacancy(c *gin.Context) {
 	}
 
 	userId, _ := c.Get("userId")
-	role, _ := c.Get("role")
-	unitId, _ := c.Get("unitKerjaId")
 
 	// Verify unit admin is creating for their own unit
-	if role == models.UserRoleUnit && unitId != nil && (*unitId.(*uuid.UUID)).String() != req.UnitKerjaID.String() {
+	if _, ok := h.authorize(c, models.PermissionVacancyManage, &req.UnitKerjaID); !ok {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: can only create vacancy for your own unit"})
 		return
 	}
@@ -110,18 +171,30 @@ func (h *Handler) CreateVacancy(c *gin.Context) {
 		return
 	}
 
+	questions, err := buildQuestions(req.Questions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	creator := userId.(uuid.UUID)
 	vacancy := models.Vacancy{
-		Title:          req.Title,
-		UnitKerjaID:    req.UnitKerjaID,
-		Description:    req.Description,
-		Requirements:   req.Requirements,
-		Duration:       req.Duration,
-		DurationMonths: req.DurationMonths,
-		Location:       req.Location,
-		Quota:          req.Quota,
-		Deadline:       deadline,
-		Status:         models.VacancyStatusPending,
-		CreatedBy:      userId.(uuid.UUID),
+		Title:             req.Title,
+		UnitKerjaID:       req.UnitKerjaID,
+		Description:       req.Description,
+		Requirements:      req.Requirements,
+		RequiredDocuments: req.RequiredDocuments,
+		Duration:          req.Duration,
+		DurationMonths:    req.DurationMonths,
+		Location:          req.Location,
+		Quota:             req.Quota,
+		Deadline:          deadline,
+		Status:            models.VacancyStatusPending,
+		CreatedBy:         creator,
+		SubmittedBy:       &creator,
+		SubmittedAt:       &now,
+		Questions:         questions,
 	}
 
 	if err := h.VacancyRepo.Create(&vacancy); err != nil {
@@ -132,9 +205,136 @@ func (h *Handler) CreateVacancy(c *gin.Context) {
 	c.JSON(http.StatusCreated, vacancy)
 }
 
+// CreateVacancyDraft for unit admin
+// @Summary Save a vacancy draft
+// @Description Save an incomplete vacancy as a draft. Drafts are only visible to the owning unit until submitted for approval.
+// @Tags Vacancies
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body VacancyDraftRequest true "Vacancy draft request"
+// @Success 201 {object} models.Vacancy
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/drafts [post]
+func (h *Handler) CreateVacancyDraft(c *gin.Context) {
+	var req VacancyDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+
+	if _, ok := h.authorize(c, models.PermissionVacancyManage, &req.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: can only create vacancy for your own unit"})
+		return
+	}
+
+	vacancy := models.Vacancy{
+		Status:    models.VacancyStatusDraft,
+		CreatedBy: userId.(uuid.UUID),
+	}
+	if err := req.apply(&vacancy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	questions, err := buildQuestions(req.Questions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	vacancy.Questions = questions
+
+	if err := h.VacancyRepo.Create(&vacancy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vacancy"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, vacancy)
+}
+
+// SubmitVacancy for unit admin
+// @Summary Submit a draft for approval
+// @Description Submit a complete draft vacancy to the central approval queue. Its status becomes 'pending'.
+// @Tags Vacancies
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Success 200 {object} models.Vacancy
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/submit [post]
+func (h *Handler) SubmitVacancy(c *gin.Context) {
+	id := c.Param("id")
+	vacancy, err := h.VacancyRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	if _, ok := h.authorize(c, models.PermissionVacancyManage, &vacancy.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: vacancy belongs to another unit"})
+		return
+	}
+
+	if vacancy.Status != models.VacancyStatusDraft {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only draft vacancies can be submitted for approval"})
+		return
+	}
+
+	if missing := missingVacancyFields(vacancy); len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Draft is incomplete", "missingFields": missing})
+		return
+	}
+
+	now := time.Now()
+	submitter := userId.(uuid.UUID)
+	vacancy.Status = models.VacancyStatusPending
+	vacancy.SubmittedBy = &submitter
+	vacancy.SubmittedAt = &now
+
+	if err := h.VacancyRepo.Update(&vacancy, models.VacancyStatusDraft, nil); err != nil {
+		respondVacancyUpdateError(c, err, "Failed to submit vacancy")
+		return
+	}
+
+	c.JSON(http.StatusOK, vacancy)
+}
+
+// GetApprovalQueue for central admin
+// @Summary List vacancies awaiting approval
+// @Description Fetch pending vacancies with their submitter and submission time, oldest submission first (for Central Admins).
+// @Tags Vacancies
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Vacancy
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/approval-queue [get]
+func (h *Handler) GetApprovalQueue(c *gin.Context) {
+	pagination := utils.GetPaginationRequest(c)
+
+	vacancies, total, err := h.VacancyRepo.FindApprovalQueue(pagination.Page, pagination.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approval queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.PaginatedResponse{
+		Data: vacancies,
+		Meta: utils.CreatePaginationMeta(total, pagination.Page, pagination.Limit),
+	})
+}
+
 // GetAllVacanciesAdmin for central admin to see all vacancies for approval
 // @Summary List all vacancies (Admin)
-// @Description Fetch all vacancies. Unit Admins see their unit's vacancies, Central Admins see all.
+// @Description Fetch all vacancies. Admins limited to one unit see that unit's vacancies including drafts; admins of all units see every vacancy except drafts they did not create.
 // @Tags Vacancies
 // @Security BearerAuth
 // @Produce json
@@ -143,24 +343,23 @@ func (h *Handler) CreateVacancy(c *gin.Context) {
 // @Router /vacancies/admin [get]
 // @Router /vacancies/all [get]
 func (h *Handler) GetAllVacanciesAdmin(c *gin.Context) {
-	role, _ := c.Get("role")
-	unitIdCtx, _ := c.Get("unitKerjaId")
 	status := c.Query("status")
 	search := c.Query("search")
 	unitIdQuery := c.Query("unitId")
 	pagination := utils.GetPaginationRequest(c)
 
-	var uid *uuid.UUID
-	if role == models.UserRoleUnit && unitIdCtx != nil {
-		uid = unitIdCtx.(*uuid.UUID)
-	} else if role == models.UserRoleCentral && unitIdQuery != "" {
+	// Users limited to one unit only see that unit; the others may filter by unit
+	uid, _ := h.authorize(c, models.PermissionVacancyManage, nil)
+	unitScoped := uid != nil
+	if uid == nil && unitIdQuery != "" {
 		parsedUid, err := uuid.Parse(unitIdQuery)
 		if err == nil {
 			uid = &parsedUid
 		}
 	}
 
-	vacancies, total, err := h.VacancyRepo.FindAllAdmin(role.(models.UserRole), uid, status, search, pagination.Page, pagination.Limit)
+	userId, _ := c.Get("userId")
+	vacancies, total, err := h.VacancyRepo.FindAllAdmin(unitScoped, userId.(uuid.UUID), uid, status, search, pagination.Page, pagination.Limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vacancies"})
 		return
@@ -174,7 +373,7 @@ func (h *Handler) GetAllVacanciesAdmin(c *gin.Context) {
 
 // ApproveVacancy for central admin
 // @Summary Approve or reject a vacancy
-// @Description Update the status of a vacancy (for Central Admins).
+// @Description Approve or reject a pending vacancy (for Central Admins). Rejecting needs a rejection note.
 // @Tags Vacancies
 // @Security BearerAuth
 // @Accept json
@@ -184,6 +383,7 @@ func (h *Handler) GetAllVacanciesAdmin(c *gin.Context) {
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /vacancies/{id}/approve [patch]
 func (h *Handler) ApproveVacancy(c *gin.Context) {
@@ -194,6 +394,24 @@ func (h *Handler) ApproveVacancy(c *gin.Context) {
 		return
 	}
 
+	req.RejectionNote = strings.TrimSpace(req.RejectionNote)
+	if req.Status == models.VacancyStatusRejected && req.RejectionNote == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rejectionNote is required when rejecting a vacancy"})
+		return
+	}
+
+	vacancy, err := h.VacancyRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
+		return
+	}
+
+	// Drafts must go through SubmitVacancy before they can be reviewed
+	if vacancy.Status != models.VacancyStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending vacancies can be approved or rejected"})
+		return
+	}
+
 	if err := h.VacancyRepo.UpdateStatus(id, req.Status, req.RejectionNote); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vacancy status"})
 		return
@@ -201,3 +419,291 @@ func (h *Handler) ApproveVacancy(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Vacancy status updated successfully"})
 }
+
+// UpdateVacancy for unit admin
+// @Summary Update a vacancy
+// @Description Edit an existing vacancy. Editing an approved or rejected vacancy sends it back to 'pending' for re-approval, and the changed fields are kept as a revision.
+// @Tags Vacancies
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Param request body VacancyRequest true "Vacancy update request"
+// @Success 200 {object} models.Vacancy
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id} [put]
+func (h *Handler) UpdateVacancy(c *gin.Context) {
+	id := c.Param("id")
+	vacancy, err := h.VacancyRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+
+	if _, ok := h.authorize(c, models.PermissionVacancyManage, &vacancy.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: can only edit vacancies of your own unit"})
+		return
+	}
+
+	if vacancy.Status == models.VacancyStatusClosed || vacancy.Status == models.VacancyStatusArchived {
+		c.JSON(http.StatusConflict, gin.H{"error": "Closed or archived vacancies cannot be edited"})
+		return
+	}
+
+	// Drafts may be saved incomplete and are not tracked as revisions
+	if vacancy.Status == models.VacancyStatusDraft {
+		var req VacancyDraftRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if _, ok := h.authorize(c, models.PermissionVacancyManage, &req.UnitKerjaID); !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: can only edit vacancies of your own unit"})
+			return
+		}
+
+		if err := req.apply(&vacancy); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := h.VacancyRepo.Update(&vacancy, models.VacancyStatusDraft, nil); err != nil {
+			respondVacancyUpdateError(c, err, "Failed to update vacancy")
+			return
+		}
+
+		c.JSON(http.StatusOK, vacancy)
+		return
+	}
+
+	var req VacancyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify unit admin is not moving the vacancy to another unit
+	if _, ok := h.authorize(c, models.PermissionVacancyManage, &req.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: can only edit vacancies of your own unit"})
+		return
+	}
+
+	deadline, err := time.Parse("2006-01-02", req.Deadline)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deadline format, use YYYY-MM-DD"})
+		return
+	}
+
+	updated := vacancy
+	updated.Title = req.Title
+	updated.UnitKerjaID = req.UnitKerjaID
+	updated.Description = req.Description
+	updated.Requirements = req.Requirements
+	updated.RequiredDocuments = req.RequiredDocuments
+	updated.Duration = req.Duration
+	updated.DurationMonths = req.DurationMonths
+	updated.Location = req.Location
+	updated.Quota = req.Quota
+	updated.Deadline = deadline
+
+	changes := diffVacancy(vacancy, updated)
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, vacancy)
+		return
+	}
+
+	// Approved and rejected vacancies need central re-approval after an edit
+	if vacancy.Status == models.VacancyStatusApproved || vacancy.Status == models.VacancyStatusRejected {
+		now := time.Now()
+		submitter := userId.(uuid.UUID)
+		updated.Status = models.VacancyStatusPending
+		updated.RejectionNote = ""
+		updated.SubmittedBy = &submitter
+		updated.SubmittedAt = &now
+	}
+
+	revision := models.VacancyRevision{
+		EditedBy:       userId.(uuid.UUID),
+		PreviousStatus: vacancy.Status,
+		Changes:        changes,
+	}
+
+	if err := h.VacancyRepo.Update(&updated, vacancy.Status, &revision); err != nil {
+		respondVacancyUpdateError(c, err, "Failed to update vacancy")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// respondVacancyUpdateError writes the response for a failed VacancyRepo.Update
+func respondVacancyUpdateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrVacancyChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": "Vacancy was changed in the meantime, please reload it"})
+	case errors.Is(err, repository.ErrQuotaBelowTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Quota cannot be lower than the seats already taken"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// CloseVacancy for unit admin
+// @Summary Close a vacancy
+// @Description Close a pending or approved vacancy early so it stops accepting applications.
+// @Tags Vacancies
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/close [patch]
+func (h *Handler) CloseVacancy(c *gin.Context) {
+	h.changeVacancyLifecycle(c, models.VacancyStatusClosed,
+		[]models.VacancyStatus{models.VacancyStatusPending, models.VacancyStatusApproved})
+}
+
+// ArchiveVacancy for unit admin
+// @Summary Archive a vacancy
+// @Description Archive a closed or rejected vacancy. Archived vacancies are hidden from the admin list unless filtered by status.
+// @Tags Vacancies
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/archive [patch]
+func (h *Handler) ArchiveVacancy(c *gin.Context) {
+	h.changeVacancyLifecycle(c, models.VacancyStatusArchived,
+		[]models.VacancyStatus{models.VacancyStatusClosed, models.VacancyStatusRejected})
+}
+
+func (h *Handler) changeVacancyLifecycle(c *gin.Context, target models.VacancyStatus, allowedFrom []models.VacancyStatus) {
+	id := c.Param("id")
+	vacancy, err := h.VacancyRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
+		return
+	}
+
+	if _, ok := h.authorize(c, models.PermissionVacancyManage, &vacancy.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: vacancy belongs to another unit"})
+		return
+	}
+
+	allowed := false
+	for _, status := range allowedFrom {
+		if vacancy.Status == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Vacancy with status '%s' cannot be %s", vacancy.Status, target)})
+		return
+	}
+
+	if err := h.VacancyRepo.UpdateStatus(id, target, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vacancy status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vacancy status updated successfully"})
+}
+
+// GetVacancyRevisions lists the edit history of a vacancy
+// @Summary List vacancy revisions (Admin)
+// @Description Fetch the recorded edits of a vacancy, newest first, with the old and new value of each changed field.
+// @Tags Vacancies
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Success 200 {array} models.VacancyRevision
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/revisions [get]
+func (h *Handler) GetVacancyRevisions(c *gin.Context) {
+	id := c.Param("id")
+	vacancy, err := h.VacancyRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vacancy not found"})
+		return
+	}
+
+	if _, ok := h.authorize(c, models.PermissionVacancyManage, &vacancy.UnitKerjaID); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: vacancy belongs to another unit"})
+		return
+	}
+
+	revisions, err := h.VacancyRepo.FindRevisions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vacancy revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// missingVacancyFields lists the fields a draft still needs before it can be submitted
+func missingVacancyFields(v models.Vacancy) []string {
+	var missing []string
+	if v.Description == "" {
+		missing = append(missing, "description")
+	}
+	if len(v.Requirements) == 0 {
+		missing = append(missing, "requirements")
+	}
+	if v.Duration == "" {
+		missing = append(missing, "duration")
+	}
+	if v.DurationMonths <= 0 {
+		missing = append(missing, "durationMonths")
+	}
+	if v.Location == "" {
+		missing = append(missing, "location")
+	}
+	if v.Quota <= 0 {
+		missing = append(missing, "quota")
+	}
+	if v.Deadline.IsZero() {
+		missing = append(missing, "deadline")
+	}
+	return missing
+}
+
+// diffVacancy returns the editable fields that differ between two versions of a vacancy
+func diffVacancy(old, updated models.Vacancy) []models.VacancyFieldChange {
+	var changes []models.VacancyFieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, models.VacancyFieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	add("title", old.Title, updated.Title)
+	add("unitKerjaId", old.UnitKerjaID.String(), updated.UnitKerjaID.String())
+	add("description", old.Description, updated.Description)
+	add("requirements", strings.Join(old.Requirements, "\n"), strings.Join(updated.Requirements, "\n"))
+	add("requiredDocuments", strings.Join(old.RequiredDocuments, ", "), strings.Join(updated.RequiredDocuments, ", "))
+	add("duration", old.Duration, updated.Duration)
+	add("durationMonths", strconv.Itoa(old.DurationMonths), strconv.Itoa(updated.DurationMonths))
+	add("location", old.Location, updated.Location)
+	add("quota", strconv.Itoa(old.Quota), strconv.Itoa(updated.Quota))
+	add("deadline", old.Deadline.Format("2006-01-02"), updated.Deadline.Format("2006-01-02"))
+
+	return changes
+}
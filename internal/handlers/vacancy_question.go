@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VacancyQuestionRequest struct {
+	// ID is set when editing an existing question
+	ID       uuid.UUID           `json:"id"`
+	Label    string              `json:"label" binding:"required,max=500"`
+	Type     models.QuestionType `json:"type" binding:"required,oneof=text choice number file"`
+	Required bool                `json:"required"`
+	Options  []string            `json:"options"`
+	Multiple bool                `json:"multiple"`
+	Min      *float64            `json:"min"`
+	Max      *float64            `json:"max"`
+}
+
+type VacancyQuestionsRequest struct {
+	Questions []VacancyQuestionRequest `json:"questions" binding:"dive"`
+}
+
+// UpdateVacancyQuestions for unit admin
+// @Summary Set the application questions of a vacancy
+// @Description Replace the extra questions applicants must answer. Questions sent with their id are updated, new ones are added and missing ones removed. Answers already given keep the question text they were given for. Changing the questions of an approved or rejected vacancy sends it back for central approval.
+// @Tags Vacancies
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Param request body VacancyQuestionsRequest true "Questions in display order"
+// @Success 200 {array} models.VacancyQuestion
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/questions [put]
+func (h *Handler) UpdateVacancyQuestions(c *gin.Context) {
+	var req VacancyQuestionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vacancy, ok := h.findOwnedVacancy(c, c.Param("id"), models.PermissionVacancyManage)
+	if !ok {
+		return
+	}
+
+	questions, err := buildQuestions(req.Questions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, _ := c.Get("userId")
+	revision := &models.VacancyRevision{
+		EditedBy:       userId.(uuid.UUID),
+		PreviousStatus: vacancy.Status,
+	}
+	if before, after := describeQuestions(vacancy.Questions), describeQuestions(questions); before != after {
+		revision.Changes = []models.VacancyFieldChange{{Field: "questions", OldValue: before, NewValue: after}}
+	}
+
+	if err := h.VacancyRepo.ReplaceQuestions(vacancy.ID, questions, revision); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, questions)
+}
+
+// buildQuestions checks the question definitions and numbers them in order
+func buildQuestions(reqs []VacancyQuestionRequest) ([]models.VacancyQuestion, error) {
+	questions := make([]models.VacancyQuestion, 0, len(reqs))
+	for i, req := range reqs {
+		q := models.VacancyQuestion{
+			Position: i + 1,
+			Label:    strings.TrimSpace(req.Label),
+			Type:     req.Type,
+			Required: req.Required,
+		}
+		q.ID = req.ID
+
+		switch req.Type {
+		case models.QuestionTypeChoice:
+			seen := map[string]bool{}
+			for _, option := range req.Options {
+				option = strings.TrimSpace(option)
+				if option == "" || seen[option] {
+					continue
+				}
+				seen[option] = true
+				q.Options = append(q.Options, option)
+			}
+			if len(q.Options) == 0 {
+				return nil, fmt.Errorf("question %d: a choice question needs at least one option", i+1)
+			}
+			q.Multiple = req.Multiple
+		case models.QuestionTypeNumber:
+			if req.Min != nil && req.Max != nil && *req.Min > *req.Max {
+				return nil, fmt.Errorf("question %d: min must not be greater than max", i+1)
+			}
+			q.Min, q.Max = req.Min, req.Max
+		}
+		if q.Label == "" {
+			return nil, fmt.Errorf("question %d: label is required", i+1)
+		}
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+// describeQuestions renders the question list for the revision history
+func describeQuestions(questions []models.VacancyQuestion) string {
+	lines := make([]string, 0, len(questions))
+	for _, q := range questions {
+		line := fmt.Sprintf("%d. %s (%s", q.Position, q.Label, q.Type)
+		if q.Required {
+			line += ", required"
+		}
+		if len(q.Options) > 0 {
+			line += ": " + strings.Join(q.Options, " / ")
+		}
+		lines = append(lines, line+")")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collectAnswers validates the applicant's answers to the vacancy questions.
+// Non-file answers come as a JSON object keyed by question ID in the "answers"
+// form field; files are uploaded as "answer_<questionId>". The returned files
+// still have to be saved, keyed by the index of their answer.
+func collectAnswers(c *gin.Context, questions []models.VacancyQuestion) ([]models.ApplicationAnswer, map[int]*utils.Upload, error) {
+	raw := map[string]json.RawMessage{}
+	if field := c.PostForm("answers"); field != "" {
+		if err := json.Unmarshal([]byte(field), &raw); err != nil {
+			return nil, nil, errors.New("Format jawaban tidak valid.")
+		}
+	}
+
+	answers := make([]models.ApplicationAnswer, 0, len(questions))
+	files := map[int]*utils.Upload{}
+	for _, q := range questions {
+		answer := models.ApplicationAnswer{
+			QuestionID: q.ID,
+			Position:   q.Position,
+			Label:      q.Label,
+			Type:       q.Type,
+		}
+		invalid := fmt.Errorf("Jawaban untuk pertanyaan \"%s\" tidak valid.", q.Label)
+
+		if q.Type == models.QuestionTypeFile {
+			field := "answer_" + q.ID.String()
+			file, err := c.FormFile(field)
+			if err != nil {
+				if q.Required {
+					return nil, nil, fmt.Errorf("Pertanyaan \"%s\" wajib dijawab.", q.Label)
+				}
+				continue
+			}
+			upload, err := readUpload(file, field, utils.AttachmentPolicy)
+			if err != nil {
+				return nil, nil, err
+			}
+			files[len(answers)] = upload
+			answers = append(answers, answer)
+			continue
+		}
+
+		value, given := raw[q.ID.String()]
+		if !given || string(value) == "null" || string(value) == `""` || string(value) == "[]" {
+			if q.Required {
+				return nil, nil, fmt.Errorf("Pertanyaan \"%s\" wajib dijawab.", q.Label)
+			}
+			continue
+		}
+
+		switch q.Type {
+		case models.QuestionTypeText:
+			var text string
+			if err := json.Unmarshal(value, &text); err != nil || len(text) > 5000 {
+				return nil, nil, invalid
+			}
+			// Whitespace alone does not answer the question
+			text = strings.TrimSpace(text)
+			if text == "" {
+				if q.Required {
+					return nil, nil, fmt.Errorf("Pertanyaan \"%s\" wajib dijawab.", q.Label)
+				}
+				continue
+			}
+			answer.Value = text
+		case models.QuestionTypeNumber:
+			var number float64
+			if err := json.Unmarshal(value, &number); err != nil {
+				// Form builders often send numbers as strings
+				var text string
+				if json.Unmarshal(value, &text) != nil {
+					return nil, nil, invalid
+				}
+				if number, err = strconv.ParseFloat(strings.TrimSpace(text), 64); err != nil {
+					return nil, nil, invalid
+				}
+			}
+			if (q.Min != nil && number < *q.Min) || (q.Max != nil && number > *q.Max) {
+				return nil, nil, invalid
+			}
+			answer.Value = strconv.FormatFloat(number, 'f', -1, 64)
+		case models.QuestionTypeChoice:
+			var choices []string
+			if err := json.Unmarshal(value, &choices); err != nil {
+				var choice string
+				if json.Unmarshal(value, &choice) != nil {
+					return nil, nil, invalid
+				}
+				choices = []string{choice}
+			}
+			if len(choices) > 1 && !q.Multiple {
+				return nil, nil, invalid
+			}
+			for _, choice := range choices {
+				if !containsString(q.Options, choice) {
+					return nil, nil, invalid
+				}
+			}
+			answer.Choices = choices
+		}
+		answers = append(answers, answer)
+	}
+	return answers, files, nil
+}
+
+// saveAnswerFiles stores the uploaded answer files and records their names on the answers
+func (h *Handler) saveAnswerFiles(answers []models.ApplicationAnswer, uploads map[int]*utils.Upload) error {
+	for i, upload := range uploads {
+		name, err := h.storeUpload(upload, "answers")
+		if err != nil {
+			return err
+		}
+		answers[i].FileName = name
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
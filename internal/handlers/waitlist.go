@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WaitlistOrderRequest struct {
+	ApplicationIDs []uuid.UUID `json:"applicationIds" binding:"required"`
+}
+
+// GetWaitlist for unit admin
+// @Summary Get the waitlist of a vacancy
+// @Description Fetch the waitlisted applications of a vacancy in promotion order. When a seat frees up the first applicant is offered it automatically.
+// @Tags Applications
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Success 200 {array} models.Application
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/waitlist [get]
+func (h *Handler) GetWaitlist(c *gin.Context) {
+	vacancy, ok := h.findOwnedVacancy(c, c.Param("id"), models.PermissionApplicationView)
+	if !ok {
+		return
+	}
+
+	apps, err := h.ApplicationRepo.FindWaitlist(vacancy.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apps)
+}
+
+// ReorderWaitlist for unit admin
+// @Summary Reorder the waitlist of a vacancy
+// @Description Set the promotion order of the waitlist. The list must contain every waitlisted application of the vacancy exactly once; it fails with 409 if the waitlist changed in the meantime.
+// @Tags Applications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Vacancy ID"
+// @Param request body WaitlistOrderRequest true "Waitlisted applications in the new order"
+// @Success 200 {array} models.Application
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /vacancies/{id}/waitlist [put]
+func (h *Handler) ReorderWaitlist(c *gin.Context) {
+	var req WaitlistOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vacancy, ok := h.findOwnedVacancy(c, c.Param("id"), models.PermissionApplicationReview)
+	if !ok {
+		return
+	}
+
+	if err := h.ApplicationRepo.ReorderWaitlist(vacancy.ID, req.ApplicationIDs); err != nil {
+		if errors.Is(err, repository.ErrWaitlistChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The list must contain every waitlisted application exactly once"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder waitlist"})
+		return
+	}
+
+	apps, err := h.ApplicationRepo.FindWaitlist(vacancy.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apps)
+}
@@ -3,61 +3,109 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/dr15/internship-hub-api/internal/services"
 	"github.com/dr15/internship-hub-api/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware accepts a bearer access token while its user is active, it
+// was issued after the user's tokens were last revoked and its session has
+// not been logged out. The request runs with the user's current role and unit.
+func AuthMiddleware(userRepo repository.UserRepository, sessionRepo repository.SessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		if c.GetHeader("Authorization") == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
 			c.Abort()
 			return
 		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be Bearer token"})
-			c.Abort()
+		if !authenticate(c, userRepo, sessionRepo) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		claims, err := utils.ValidateToken(parts[1])
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+// OptionalAuthMiddleware lets anonymous requests through to public routes
+// that show more to signed-in users. A token that is sent is checked as by
+// AuthMiddleware.
+func OptionalAuthMiddleware(userRepo repository.UserRepository, sessionRepo repository.SessionRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" && !authenticate(c, userRepo, sessionRepo) {
 			return
 		}
-
-		c.Set("userId", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("role", claims.Role)
-		c.Set("unitKerjaId", claims.UnitKerjaID)
 		c.Next()
 	}
 }
 
-func RoleMiddleware(roles ...models.UserRole) gin.HandlerFunc {
+// authenticate validates the bearer token and sets the user on the context.
+// It writes the error response and aborts when the token is not accepted.
+func authenticate(c *gin.Context, userRepo repository.UserRepository, sessionRepo repository.SessionRepository) bool {
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be Bearer token"})
+		c.Abort()
+		return false
+	}
+
+	claims, err := utils.ValidateToken(parts[1])
+	if err != nil || claims.SessionID == uuid.Nil || claims.IssuedAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return false
+	}
+
+	user, err := userRepo.FindAuthState(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return false
+	}
+	if user.DeactivatedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Account has been deactivated"})
+		c.Abort()
+		return false
+	}
+	if user.TokensValidAfter != nil && claims.IssuedAt.Time.Before(user.TokensValidAfter.Truncate(time.Second)) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked, please log in again"})
+		c.Abort()
+		return false
+	}
+	session, err := sessionRepo.FindByID(claims.SessionID)
+	if err != nil || session.UserID != claims.UserID || session.RevokedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has ended, please log in again"})
+		c.Abort()
+		return false
+	}
+	// Activity tracking is best effort and must not fail the request
+	sessionRepo.Touch(&session, c.ClientIP(), c.Request.UserAgent())
+
+	// Role and unit come from the stored user, so a change applies to
+	// tokens that are already out
+	c.Set("userId", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("role", user.Role)
+	c.Set("unitKerjaId", user.UnitKerjaID)
+	c.Set("sessionId", claims.SessionID)
+	return true
+}
+
+// PermissionMiddleware lets the request through when the user's role grants
+// the permission for some unit. Handlers acting on a particular unit's data
+// check it again for that unit.
+func PermissionMiddleware(authz *services.AuthorizationService, permission models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole, exists := c.Get("role")
-		if !exists {
+		if _, exists := c.Get("role"); !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
 
-		roleMatch := false
-		for _, role := range roles {
-			if userRole.(models.UserRole) == role {
-				roleMatch = true
-				break
-			}
-		}
-
-		if !roleMatch {
+		if _, ok := authz.Authorize(CurrentPrincipal(c), permission, nil); !ok {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: insufficient permissions"})
 			c.Abort()
 			return
@@ -66,3 +114,15 @@ func RoleMiddleware(roles ...models.UserRole) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// CurrentPrincipal is the signed-in user as set by AuthMiddleware
+func CurrentPrincipal(c *gin.Context) services.Principal {
+	var p services.Principal
+	if role, ok := c.Get("role"); ok {
+		p.Role, _ = role.(models.UserRole)
+	}
+	if unitID, ok := c.Get("unitKerjaId"); ok {
+		p.UnitKerjaID, _ = unitID.(*uuid.UUID)
+	}
+	return p
+}
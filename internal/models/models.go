@@ -15,6 +15,8 @@ const (
 	VacancyStatusPending  VacancyStatus = "pending"
 	VacancyStatusApproved VacancyStatus = "approved"
 	VacancyStatusRejected VacancyStatus = "rejected"
+	VacancyStatusClosed   VacancyStatus = "closed"
+	VacancyStatusArchived VacancyStatus = "archived"
 )
 
 type ApplicationStatus string
@@ -22,9 +24,72 @@ type ApplicationStatus string
 const (
 	ApplicationStatusSubmitted ApplicationStatus = "submitted"
 	ApplicationStatusReviewed  ApplicationStatus = "reviewed"
+	ApplicationStatusInterview ApplicationStatus = "interview"
+	ApplicationStatusWaitlist  ApplicationStatus = "waitlisted"
+	ApplicationStatusOffered   ApplicationStatus = "offered"
 	ApplicationStatusAccepted  ApplicationStatus = "accepted"
+	ApplicationStatusDeclined  ApplicationStatus = "declined"
+	ApplicationStatusExpired   ApplicationStatus = "offer_expired"
 	ApplicationStatusRejected  ApplicationStatus = "rejected"
+	ApplicationStatusFinished  ApplicationStatus = "finished"
 	ApplicationStatusCompleted ApplicationStatus = "completed"
+	ApplicationStatusWithdrawn ApplicationStatus = "withdrawn"
+)
+
+// applicationTransitions lists the statuses an application may move to from
+// each status. Statuses without an entry are final.
+var applicationTransitions = map[ApplicationStatus][]ApplicationStatus{
+	ApplicationStatusSubmitted: {ApplicationStatusReviewed, ApplicationStatusInterview, ApplicationStatusWaitlist, ApplicationStatusOffered, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusReviewed:  {ApplicationStatusInterview, ApplicationStatusWaitlist, ApplicationStatusOffered, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusInterview: {ApplicationStatusWaitlist, ApplicationStatusOffered, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusWaitlist:  {ApplicationStatusOffered, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusOffered:   {ApplicationStatusAccepted, ApplicationStatusDeclined, ApplicationStatusExpired, ApplicationStatusRejected},
+	ApplicationStatusAccepted:  {ApplicationStatusFinished, ApplicationStatusCompleted, ApplicationStatusWithdrawn},
+	ApplicationStatusFinished:  {ApplicationStatusCompleted},
+}
+
+// CanTransitionTo reports whether an application may move from s to next
+func (s ApplicationStatus) CanTransitionTo(next ApplicationStatus) bool {
+	for _, allowed := range applicationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type InterviewStatus string
+
+const (
+	InterviewStatusInvited   InterviewStatus = "invited"
+	InterviewStatusScheduled InterviewStatus = "scheduled"
+	InterviewStatusCompleted InterviewStatus = "completed"
+)
+
+type InterviewOutcome string
+
+const (
+	InterviewOutcomePassed InterviewOutcome = "passed"
+	InterviewOutcomeFailed InterviewOutcome = "failed"
+	InterviewOutcomeNoShow InterviewOutcome = "no_show"
+)
+
+type QuestionType string
+
+const (
+	QuestionTypeText   QuestionType = "text"
+	QuestionTypeChoice QuestionType = "choice"
+	QuestionTypeNumber QuestionType = "number"
+	QuestionTypeFile   QuestionType = "file"
+)
+
+type DocumentType string
+
+const (
+	DocumentTypeCV                   DocumentType = "cv"
+	DocumentTypeTranscript           DocumentType = "transcript"
+	DocumentTypeRecommendationLetter DocumentType = "recommendation_letter"
+	DocumentTypeIDCard               DocumentType = "id_card"
 )
 
 type AttendanceStatus string
@@ -36,14 +101,105 @@ const (
 	AttendanceStatusAlpha   AttendanceStatus = "alpha"
 )
 
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending   ApprovalStatus = "pending"
+	ApprovalStatusApproved  ApprovalStatus = "approved"
+	ApprovalStatusRejected  ApprovalStatus = "rejected"
+	ApprovalStatusCancelled ApprovalStatus = "cancelled"
+)
+
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning   JobRunStatus = "running"
+	JobRunStatusSucceeded JobRunStatus = "succeeded"
+	JobRunStatusFailed    JobRunStatus = "failed"
+)
+
 type UserRole string
 
 const (
 	UserRoleApplicant UserRole = "applicant"
 	UserRoleUnit      UserRole = "unit"
 	UserRoleCentral   UserRole = "central"
+	UserRoleMentor    UserRole = "mentor"
+	// UserRoleCampusSupervisor is a university supervisor (dosen pembimbing)
+	// following their students' internships
+	UserRoleCampusSupervisor UserRole = "campus_supervisor"
 )
 
+// IsExternal reports whether the role belongs to people outside the
+// organisation, who sign in with a password and never through single sign-on
+func (r UserRole) IsExternal() bool {
+	return r == UserRoleApplicant || r == UserRoleCampusSupervisor
+}
+
+// Permission names one action. Roles grant sets of them.
+type Permission string
+
+const (
+	PermissionApplicationApply   Permission = "application.apply"
+	PermissionInterviewBook      Permission = "interview.book"
+	PermissionAttendanceRecord   Permission = "attendance.record"
+	PermissionInternshipReport   Permission = "internship.report"
+	PermissionVacancyManage      Permission = "vacancy.manage"
+	PermissionVacancyApprove     Permission = "vacancy.approve"
+	PermissionApplicationView    Permission = "application.view"
+	PermissionApplicationReview  Permission = "application.review"
+	PermissionInterviewManage    Permission = "interview.manage"
+	PermissionAttendanceView     Permission = "attendance.view"
+	PermissionAttendanceExport   Permission = "attendance.export"
+	PermissionInternshipEvaluate Permission = "internship.evaluate"
+	PermissionUserManage         Permission = "user.manage"
+	PermissionRoleManage         Permission = "role.manage"
+	PermissionUnitManage         Permission = "unit.manage"
+	PermissionJobView            Permission = "job.view"
+	PermissionMenteeSupervise    Permission = "mentee.supervise"
+	PermissionInternshipMonitor  Permission = "internship.monitor"
+)
+
+type PermissionInfo struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+	// UnitScopable permissions make sense within one unit; the others can
+	// only be granted to roles that act across all units
+	UnitScopable bool `json:"unitScopable"`
+}
+
+// Permissions lists every permission a role can be given
+var Permissions = []PermissionInfo{
+	{PermissionApplicationApply, "Melamar lowongan dan mengelola lamaran sendiri", false},
+	{PermissionInterviewBook, "Memilih jadwal wawancara untuk lamaran sendiri", false},
+	{PermissionAttendanceRecord, "Mencatat presensi magang sendiri", false},
+	{PermissionInternshipReport, "Mengunggah laporan magang sendiri", false},
+	{PermissionVacancyManage, "Membuat dan mengubah lowongan", true},
+	{PermissionVacancyApprove, "Menyetujui lowongan yang diajukan", false},
+	{PermissionApplicationView, "Melihat lamaran beserta berkasnya", true},
+	{PermissionApplicationReview, "Menilai, mengomentari dan memutuskan lamaran", true},
+	{PermissionInterviewManage, "Mengatur jadwal dan hasil wawancara", true},
+	{PermissionAttendanceView, "Melihat rekap presensi", true},
+	{PermissionAttendanceExport, "Mengekspor rekap presensi", true},
+	{PermissionInternshipEvaluate, "Menilai laporan akhir magang", true},
+	{PermissionUserManage, "Mengelola akun pengguna", false},
+	{PermissionRoleManage, "Mengelola peran, hak akses dan pemetaan SSO", false},
+	{PermissionUnitManage, "Mengelola unit kerja", false},
+	{PermissionJobView, "Melihat riwayat tugas terjadwal", false},
+	{PermissionMenteeSupervise, "Membimbing peserta magang yang ditugaskan: presensi, logbook, izin dan nilai", true},
+	{PermissionInternshipMonitor, "Memantau magang mahasiswa yang mengundang sebagai dosen pembimbing", false},
+}
+
+// FindPermission returns the catalog entry of a permission name
+func FindPermission(name Permission) (PermissionInfo, bool) {
+	for _, p := range Permissions {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PermissionInfo{}, false
+}
+
 type Base struct {
 	ID        uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
 	CreatedAt time.Time      `json:"createdAt"`
@@ -74,6 +230,28 @@ type User struct {
 	Semester    int        `json:"semester"`
 	ResetToken  string     `json:"-"`
 	ResetExpiry *time.Time `json:"-"`
+	// Self-registered applicants stay unverified until they confirm the link
+	// sent to their email address
+	EmailVerifiedAt    *time.Time `json:"emailVerifiedAt"`
+	VerificationToken  string     `gorm:"index" json:"-"`
+	VerificationSentAt *time.Time `json:"-"`
+	// Two-factor authentication. The pending secret is kept until the first
+	// code confirms enrollment; the last counter stops codes being replayed.
+	TOTPSecret        string     `json:"-"`
+	TOTPPendingSecret string     `json:"-"`
+	TOTPEnabledAt     *time.Time `json:"totpEnabledAt"`
+	TOTPLastCounter   int64      `json:"-"`
+	// Staff signing in through the identity provider are linked by its subject
+	OIDCIssuer  *string `gorm:"uniqueIndex:idx_user_oidc_subject" json:"-"`
+	OIDCSubject *string `gorm:"uniqueIndex:idx_user_oidc_subject" json:"-"`
+
+	// Permissions granted by the user's role, filled in for their own profile
+	Permissions []string `gorm:"-" json:"permissions,omitempty"`
+	// Access tokens issued before this moment are rejected. It moves forward
+	// on password changes and deactivation.
+	TokensValidAfter *time.Time `json:"-"`
+	DeactivatedAt    *time.Time `json:"deactivatedAt,omitempty"`
+	ActiveSessions   *int64     `gorm:"-" json:"activeSessions,omitempty"`
 }
 
 type UnitKerja struct {
@@ -84,45 +262,207 @@ type UnitKerja struct {
 
 type Vacancy struct {
 	Base
-	Title          string         `json:"title"`
-	UnitKerjaID    uuid.UUID      `json:"unitKerjaId"`
-	UnitKerja      UnitKerja      `json:"unitKerja"`
-	Description    string         `json:"description"`
-	Requirements   pq.StringArray `gorm:"type:text[]" json:"requirements"`
-	Duration       string         `json:"duration"`
-	DurationMonths int            `json:"durationMonths"`
-	Location       string         `json:"location"`
-	Quota          int            `json:"quota"`
-	Deadline       time.Time      `json:"deadline"`
-	Status         VacancyStatus  `json:"status"`
-	CreatedBy      uuid.UUID      `json:"createdBy"`
-	RejectionNote  string         `json:"rejectionNote,omitempty"`
+	Title             string            `json:"title"`
+	UnitKerjaID       uuid.UUID         `json:"unitKerjaId"`
+	UnitKerja         UnitKerja         `json:"unitKerja"`
+	Description       string            `json:"description"`
+	Requirements      pq.StringArray    `gorm:"type:text[]" json:"requirements"`
+	RequiredDocuments pq.StringArray    `gorm:"type:text[]" json:"requiredDocuments"`
+	Duration          string            `json:"duration"`
+	DurationMonths    int               `json:"durationMonths"`
+	Location          string            `json:"location"`
+	Quota             int               `json:"quota"`
+	Deadline          time.Time         `json:"deadline"`
+	Status            VacancyStatus     `json:"status"`
+	CreatedBy         uuid.UUID         `json:"createdBy"`
+	SubmittedBy       *uuid.UUID        `json:"submittedBy,omitempty"`
+	Submitter         *User             `gorm:"foreignKey:SubmittedBy" json:"submitter,omitempty"`
+	SubmittedAt       *time.Time        `json:"submittedAt,omitempty"`
+	RejectionNote     string            `json:"rejectionNote,omitempty"`
+	ClosedAt          *time.Time        `json:"closedAt,omitempty"`
+	ApplicantCount    int64             `gorm:"-" json:"applicantCount"`
+	AcceptedCount     int64             `gorm:"-" json:"acceptedCount"`
+	RemainingSeats    int               `gorm:"-" json:"remainingSeats"`
+	Questions         []VacancyQuestion `json:"questions,omitempty"`
+}
+
+// IsPastDeadline reports whether applications are no longer accepted. The
+// deadline date itself is still open until the end of the day.
+func (v Vacancy) IsPastDeadline(now time.Time) bool {
+	y, m, d := v.Deadline.Date()
+	return !now.Before(time.Date(y, m, d+1, 0, 0, 0, 0, v.Deadline.Location()))
+}
+
+// VacancyRevision records a single edit made to a vacancy so approvers can
+// review what changed before re-approving it.
+type VacancyRevision struct {
+	Base
+	VacancyID      uuid.UUID            `gorm:"index" json:"vacancyId"`
+	EditedBy       uuid.UUID            `json:"editedBy"`
+	Editor         User                 `gorm:"foreignKey:EditedBy" json:"editor"`
+	PreviousStatus VacancyStatus        `json:"previousStatus"`
+	Changes        []VacancyFieldChange `gorm:"foreignKey:RevisionID" json:"changes"`
+}
+
+type VacancyFieldChange struct {
+	Base
+	RevisionID uuid.UUID `gorm:"index" json:"revisionId"`
+	Field      string    `json:"field"`
+	OldValue   string    `json:"oldValue"`
+	NewValue   string    `json:"newValue"`
 }
 
 type Application struct {
 	Base
-	UserID        uuid.UUID         `json:"userId"`
-	User          User              `json:"user"`
-	VacancyID     uuid.UUID         `json:"vacancyId"`
-	Vacancy       Vacancy           `json:"vacancy"`
-	Phone         string            `json:"phone"`
-	University    string            `json:"university"`
-	Major         string            `json:"major"`
-	Semester      int               `json:"semester"`
-	Motivation    string            `json:"motivation"`
-	CVFileName    string            `json:"cvFileName"`
-	Status        ApplicationStatus `json:"status"`
-	AppliedAt     time.Time         `json:"appliedAt"`
-	RejectionNote string            `json:"rejectionNote,omitempty"`
+	UserID         uuid.UUID         `json:"userId"`
+	User           User              `json:"user"`
+	VacancyID      uuid.UUID         `json:"vacancyId"`
+	Vacancy        Vacancy           `json:"vacancy"`
+	Phone          string            `json:"phone"`
+	University     string            `json:"university"`
+	Major          string            `json:"major"`
+	Semester       int               `json:"semester"`
+	Motivation     string            `json:"motivation"`
+	CVFileName     string            `json:"cvFileName"`
+	Status         ApplicationStatus `json:"status"`
+	AppliedAt      time.Time         `json:"appliedAt"`
+	RejectionNote  string            `json:"rejectionNote,omitempty"`
+	WithdrawnAt    *time.Time        `json:"withdrawnAt,omitempty"`
+	WithdrawReason string            `json:"withdrawReason,omitempty"`
+	OfferedAt      *time.Time        `json:"offeredAt,omitempty"`
+	OfferExpiresAt *time.Time        `json:"offerExpiresAt,omitempty"`
+	RespondedAt    *time.Time        `json:"respondedAt,omitempty"`
+	DeclineReason  string            `json:"declineReason,omitempty"`
+	// WaitlistPosition orders waitlisted applications of a vacancy, lowest first
+	WaitlistPosition *int `json:"waitlistPosition,omitempty"`
+	// MentorID is the mentor supervising the intern day to day
+	MentorID      *uuid.UUID            `gorm:"index" json:"mentorId,omitempty"`
+	Mentor        *User                 `gorm:"foreignKey:MentorID" json:"mentor,omitempty"`
+	Answers       []ApplicationAnswer   `json:"answers,omitempty"`
+	Documents     []ApplicationDocument `json:"documents,omitempty"`
+	Interview     *Interview            `json:"interview,omitempty"`
+	AverageRating *float64              `gorm:"-" json:"averageRating,omitempty"`
+	RatingCount   int64                 `gorm:"-" json:"ratingCount,omitempty"`
+}
+
+// ApplicationStatusHistory is one entry in the status timeline of an application
+type ApplicationStatusHistory struct {
+	Base
+	ApplicationID uuid.UUID         `gorm:"index" json:"applicationId"`
+	ActorID       *uuid.UUID        `json:"actorId"`
+	Actor         *User             `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	FromStatus    ApplicationStatus `json:"fromStatus"`
+	ToStatus      ApplicationStatus `json:"toStatus"`
+	Note          string            `json:"note"`
+}
+
+func (ApplicationStatusHistory) TableName() string {
+	return "application_status_history"
+}
+
+// VacancyQuestion is an extra question a unit asks applicants of a vacancy
+type VacancyQuestion struct {
+	Base
+	VacancyID uuid.UUID    `gorm:"index" json:"vacancyId"`
+	Position  int          `json:"position"`
+	Label     string       `json:"label"`
+	Type      QuestionType `json:"type"`
+	Required  bool         `json:"required"`
+	// Options are the allowed answers of a choice question
+	Options pq.StringArray `gorm:"type:text[]" json:"options,omitempty"`
+	// Multiple lets a choice question be answered with several options
+	Multiple bool     `json:"multiple,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+}
+
+// ApplicationAnswer is an applicant's answer to a vacancy question. The label
+// and type are copied so the answer stays readable if the question changes.
+type ApplicationAnswer struct {
+	Base
+	ApplicationID uuid.UUID      `gorm:"index" json:"applicationId"`
+	QuestionID    uuid.UUID      `json:"questionId"`
+	Position      int            `json:"position"`
+	Label         string         `json:"label"`
+	Type          QuestionType   `json:"type"`
+	Value         string         `json:"value,omitempty"`
+	Choices       pq.StringArray `gorm:"type:text[]" json:"choices,omitempty"`
+	FileName      string         `json:"fileName,omitempty"`
+}
+
+// ApplicationDocument is a supporting file attached to an application, one per type
+type ApplicationDocument struct {
+	Base
+	ApplicationID uuid.UUID    `gorm:"uniqueIndex:idx_document_application_type" json:"applicationId"`
+	Type          DocumentType `gorm:"uniqueIndex:idx_document_application_type" json:"type"`
+	FileName      string       `json:"fileName"`
+	OriginalName  string       `json:"originalName"`
+	Size          int64        `json:"size"`
+	Hash          string       `json:"hash"`
+}
+
+// StoredFile records an uploaded file by its content hash. Files are stored
+// under their hash, so identical uploads to the same directory share one file.
+type StoredFile struct {
+	Base
+	Dir         string `gorm:"uniqueIndex:idx_stored_file_dir_hash" json:"dir"`
+	Hash        string `gorm:"uniqueIndex:idx_stored_file_dir_hash" json:"hash"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+// ApplicationRating is one reviewer's 1-5 score of an applicant
+type ApplicationRating struct {
+	Base
+	ApplicationID uuid.UUID `gorm:"uniqueIndex:idx_rating_application_reviewer" json:"applicationId"`
+	ReviewerID    uuid.UUID `gorm:"uniqueIndex:idx_rating_application_reviewer" json:"reviewerId"`
+	Reviewer      User      `gorm:"foreignKey:ReviewerID" json:"reviewer"`
+	Rating        int       `json:"rating"`
+}
+
+// ApplicationComment is a private note left by admin staff on an application
+type ApplicationComment struct {
+	Base
+	ApplicationID uuid.UUID `gorm:"index" json:"applicationId"`
+	AuthorID      uuid.UUID `json:"authorId"`
+	Author        User      `gorm:"foreignKey:AuthorID" json:"author"`
+	Body          string    `json:"body"`
+}
+
+// InterviewSlot is a time window a unit offers for interviews on a vacancy
+type InterviewSlot struct {
+	Base
+	VacancyID   uuid.UUID `gorm:"index" json:"vacancyId"`
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+	Location    string    `json:"location"`
+	Capacity    int       `json:"capacity"`
+	CreatedBy   uuid.UUID `json:"createdBy"`
+	BookedCount int64     `gorm:"-" json:"bookedCount"`
+}
+
+// Interview tracks the interview of one application, from invitation to outcome
+type Interview struct {
+	Base
+	ApplicationID    uuid.UUID        `gorm:"uniqueIndex" json:"applicationId"`
+	SlotID           *uuid.UUID       `gorm:"index" json:"slotId"`
+	Slot             *InterviewSlot   `json:"slot,omitempty"`
+	Status           InterviewStatus  `json:"status"`
+	Sequence         int              `json:"sequence"`
+	InvitedBy        uuid.UUID        `json:"invitedBy"`
+	Outcome          InterviewOutcome `json:"outcome,omitempty"`
+	InterviewerNotes string           `json:"interviewerNotes,omitempty"`
+	ReviewedBy       *uuid.UUID       `json:"reviewedBy,omitempty"`
 }
 
 type Attendance struct {
 	Base
-	UserID        uuid.UUID        `json:"userId"`
+	UserID        uuid.UUID        `gorm:"uniqueIndex:idx_attendance_user_day" json:"userId"`
 	User          User             `json:"user"`
 	ApplicationID uuid.UUID        `json:"applicationId"`
 	Application   Application      `json:"application"`
-	Date          time.Time        `gorm:"type:date;index:idx_attendance_user_date,unique" json:"date"`
+	Date          time.Time        `gorm:"type:date;uniqueIndex:idx_attendance_user_day" json:"date"`
 	CheckIn       *time.Time       `json:"checkIn"`
 	CheckOut      *time.Time       `json:"checkOut"`
 	Status        AttendanceStatus `json:"status"`
@@ -147,4 +487,174 @@ type InternshipResult struct {
 	ReviewNotes          string      `json:"reviewNotes"`
 	ReviewedBy           uuid.UUID   `json:"reviewedBy"`
 	ReviewedAt           *time.Time  `json:"reviewedAt"`
+	// MentorEvaluation is the mentor's input; the reviewer's scores above are final
+	MentorEvaluation *MentorEvaluation `gorm:"foreignKey:ApplicationID;references:ApplicationID;constraint:-" json:"mentorEvaluation,omitempty"`
+}
+
+// JobRun records one execution of a scheduled background job
+type JobRun struct {
+	Base
+	Name       string       `gorm:"index" json:"name"`
+	Instance   string       `json:"instance"`
+	Status     JobRunStatus `json:"status"`
+	Affected   int64        `json:"affected"`
+	Error      string       `json:"error,omitempty"`
+	StartedAt  time.Time    `gorm:"index" json:"startedAt"`
+	FinishedAt *time.Time   `json:"finishedAt"`
+}
+
+// RefreshToken is one link of a rotating refresh token chain. Only a hash of
+// the token is stored. Every login starts a new family, identified by the ID
+// of its Session; each refresh rotates the token within its family, and
+// presenting a rotated token again revokes the whole family.
+type RefreshToken struct {
+	Base
+	UserID    uuid.UUID  `gorm:"index" json:"userId"`
+	FamilyID  uuid.UUID  `gorm:"index" json:"familyId"`
+	TokenHash string     `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"index" json:"expiresAt"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Session is one signed-in device or browser. Its ID is the family ID of the
+// refresh tokens issued to it and the session ID carried by access tokens.
+type Session struct {
+	Base
+	UserID       uuid.UUID  `gorm:"index" json:"userId"`
+	UserAgent    string     `json:"userAgent"`
+	IPAddress    string     `json:"ipAddress"`
+	LastActiveAt time.Time  `json:"lastActiveAt"`
+	ExpiresAt    time.Time  `gorm:"index" json:"expiresAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	Current      bool       `gorm:"-" json:"current"`
+}
+
+// AuthThrottle counts recent failed sign-in attempts, or password reset
+// requests, for one account or IP address. Key is e.g. "login:account:<email>"
+// or "login:ip:<address>". Rows live in the database so every replica sees
+// the same counters.
+type AuthThrottle struct {
+	Base
+	Key           string     `gorm:"uniqueIndex" json:"key"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `gorm:"index" json:"lastFailureAt"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"`
+}
+
+// RecoveryCode is a one-time code that stands in for a TOTP code when the
+// authenticator is lost. Only a hash is stored.
+type RecoveryCode struct {
+	Base
+	UserID   uuid.UUID  `gorm:"index" json:"userId"`
+	CodeHash string     `gorm:"index" json:"-"`
+	UsedAt   *time.Time `json:"usedAt,omitempty"`
+}
+
+// OIDCLoginState tracks one single sign-on attempt, from the redirect to the
+// identity provider until the frontend exchanges the one-time login code
+type OIDCLoginState struct {
+	Base
+	State        string     `gorm:"uniqueIndex" json:"-"`
+	Nonce        string     `json:"-"`
+	CodeVerifier string     `json:"-"`
+	ExpiresAt    time.Time  `gorm:"index" json:"expiresAt"`
+	UsedAt       *time.Time `json:"usedAt,omitempty"`
+	UserID       *uuid.UUID `json:"userId,omitempty"`
+	LoginCode    string     `gorm:"index" json:"-"`
+	ExchangedAt  *time.Time `json:"exchangedAt,omitempty"`
+}
+
+// OIDCRoleMapping gives users whose identity provider claim has the given
+// value a role, and for unit admins a unit. Rules are tried by ascending
+// priority and the first match wins.
+type OIDCRoleMapping struct {
+	Base
+	Claim       string     `json:"claim"`
+	Value       string     `json:"value"`
+	Role        UserRole   `json:"role"`
+	UnitKerjaID *uuid.UUID `json:"unitKerjaId,omitempty"`
+	UnitKerja   *UnitKerja `json:"unitKerja,omitempty"`
+	Priority    int        `json:"priority"`
+}
+
+// Role is a named set of permissions. Users refer to it by name. A unit
+// scoped role only acts within the user's own unit.
+type Role struct {
+	Base
+	Name             UserRole       `gorm:"uniqueIndex" json:"name"`
+	Description      string         `json:"description"`
+	Permissions      pq.StringArray `gorm:"type:text[]" json:"permissions"`
+	UnitScoped       bool           `json:"unitScoped"`
+	RequireTwoFactor bool           `json:"requireTwoFactor"`
+	// System roles are built in and cannot be deleted
+	System bool `json:"system"`
+}
+
+// Has reports whether the role grants the permission
+func (r Role) Has(permission Permission) bool {
+	for _, p := range r.Permissions {
+		if Permission(p) == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// LogbookEntry is an intern's account of one day's work, approved by their mentor
+type LogbookEntry struct {
+	Base
+	ApplicationID uuid.UUID      `gorm:"uniqueIndex:idx_logbook_application_day" json:"applicationId"`
+	UserID        uuid.UUID      `gorm:"index" json:"userId"`
+	Date          time.Time      `gorm:"type:date;uniqueIndex:idx_logbook_application_day" json:"date"`
+	Activities    string         `json:"activities"`
+	Status        ApprovalStatus `json:"status"`
+	ReviewedBy    *uuid.UUID     `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time     `json:"reviewedAt,omitempty"`
+	ReviewNote    string         `json:"reviewNote,omitempty"`
+}
+
+// LeaveRequest asks the mentor to excuse the intern for a range of days.
+// Approved days are recorded as leave in the attendance.
+type LeaveRequest struct {
+	Base
+	ApplicationID uuid.UUID      `gorm:"index" json:"applicationId"`
+	Application   *Application   `json:"application,omitempty"`
+	UserID        uuid.UUID      `gorm:"index" json:"userId"`
+	StartDate     time.Time      `gorm:"type:date" json:"startDate"`
+	EndDate       time.Time      `gorm:"type:date" json:"endDate"`
+	Reason        string         `json:"reason"`
+	Status        ApprovalStatus `json:"status"`
+	ReviewedBy    *uuid.UUID     `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time     `json:"reviewedAt,omitempty"`
+	ReviewNote    string         `json:"reviewNote,omitempty"`
+}
+
+// MentorEvaluation is the mentor's score input for an intern, which the unit
+// admin reviewing the internship may adopt or override
+type MentorEvaluation struct {
+	Base
+	ApplicationID    uuid.UUID `gorm:"uniqueIndex" json:"applicationId"`
+	MentorID         uuid.UUID `json:"mentorId"`
+	Mentor           *User     `gorm:"foreignKey:MentorID" json:"mentor,omitempty"`
+	PerformanceScore float64   `json:"performanceScore"`
+	DisciplineScore  float64   `json:"disciplineScore"`
+	Notes            string    `json:"notes"`
+}
+
+// CampusSupervisor gives a university supervisor read-only access to one
+// student's internship. It starts as an emailed invitation and is linked to
+// the supervisor's account once accepted.
+type CampusSupervisor struct {
+	Base
+	ApplicationID uuid.UUID    `gorm:"index" json:"applicationId"`
+	Application   *Application `json:"application,omitempty"`
+	Email         string       `json:"email"`
+	Name          string       `json:"name"`
+	SupervisorID  *uuid.UUID   `gorm:"index" json:"supervisorId,omitempty"`
+	InvitedBy     uuid.UUID    `json:"invitedBy"`
+	Token         string       `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt     time.Time    `json:"expiresAt"`
+	AcceptedAt    *time.Time   `json:"acceptedAt,omitempty"`
+	RevokedAt     *time.Time   `json:"revokedAt,omitempty"`
 }
@@ -1,19 +1,72 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/dr15/internship-hub-api/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVacancyNotOpen   = errors.New("vacancy is not open for applications")
+	ErrDeadlinePassed   = errors.New("vacancy application deadline has passed")
+	ErrQuotaFull        = errors.New("vacancy quota is full")
+	ErrNotWithdrawable  = errors.New("application can no longer be withdrawn")
+	ErrNoOpenOffer      = errors.New("application has no open offer")
+	ErrOfferExpired     = errors.New("offer has expired")
+	ErrWaitlistChanged  = errors.New("waitlist does not match the given order")
+	ErrNotEditable      = errors.New("application can no longer be changed")
+	ErrActiveInternship = errors.New("applicant already has an ongoing internship")
+	ErrAlreadyApplied   = errors.New("applicant already applied to this vacancy")
 )
 
+// InvalidTransitionError is returned when a status change is not allowed by
+// the application state machine.
+type InvalidTransitionError struct {
+	From models.ApplicationStatus
+	To   models.ApplicationStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot change application status from '%s' to '%s'", e.From, e.To)
+}
+
+// SeatHoldingStatuses are the application statuses that occupy a seat of the vacancy quota
+var SeatHoldingStatuses = []models.ApplicationStatus{
+	models.ApplicationStatusOffered,
+	models.ApplicationStatusAccepted,
+	models.ApplicationStatusFinished,
+	models.ApplicationStatusCompleted,
+}
+
 type ApplicationRepository interface {
 	Create(app *models.Application) error
+	Submit(app *models.Application) error
+	Offer(id string, note string, expiresAt time.Time, actorID uuid.UUID) (models.ApplicationStatus, error)
+	AcceptOffer(id string, actorID uuid.UUID) error
+	DeclineOffer(id string, reason string, actorID uuid.UUID) error
+	ExpireOffers() ([]models.Application, error)
+	PromoteWaitlisted(vacancyID uuid.UUID, expiresAt time.Time) ([]models.Application, error)
+	FindWaitlistedVacancyIDs() ([]uuid.UUID, error)
+	FindWaitlist(vacancyID string) ([]models.Application, error)
+	ReorderWaitlist(vacancyID uuid.UUID, order []uuid.UUID) error
+	Withdraw(id string, reason string, actorID uuid.UUID) error
 	FindByUserAndVacancy(userID, vacancyID uuid.UUID) (models.Application, error)
 	FindByUserID(userID uuid.UUID, page, limit int) ([]models.Application, int64, error)
-	FindByVacancyID(vacancyID string, search string, page, limit int) ([]models.Application, int64, error)
-	UpdateStatus(id string, status models.ApplicationStatus, rejectionNote string) error
+	FindByVacancyID(vacancyID string, search string, sort string, page, limit int) ([]models.Application, int64, error)
+	FindIDsByVacancy(vacancyID string, status models.ApplicationStatus, search string) ([]uuid.UUID, error)
+	FindAllByVacancy(vacancyID string) ([]models.Application, error)
+	ReplaceDocument(appID string, doc *models.ApplicationDocument) error
+	UpdateStatus(id string, status models.ApplicationStatus, note string, actorID uuid.UUID) error
+	FindStatusHistory(appID string) ([]models.ApplicationStatusHistory, error)
 	FindByID(id string) (models.Application, error)
+	FindWithFiles(id string) (models.Application, error)
 	CountAcceptedByUser(userID uuid.UUID) (int64, error)
+	FinishEnded() (int64, error)
 	Update(app *models.Application) error
 }
 
@@ -29,29 +82,417 @@ func (r *applicationRepository) Create(app *models.Application) error {
 	return r.db.Create(app).Error
 }
 
+// transition moves a locked application to the given status together with
+// any extra column updates, and records the change in the status history.
+// A nil actor means the change was made by the system.
+func transition(tx *gorm.DB, app *models.Application, to models.ApplicationStatus, actorID *uuid.UUID, note string, updates map[string]interface{}) error {
+	if !app.Status.CanTransitionTo(to) {
+		return &InvalidTransitionError{From: app.Status, To: to}
+	}
+
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	if app.Status == models.ApplicationStatusWaitlist && to != models.ApplicationStatusWaitlist {
+		updates["waitlist_position"] = nil
+	}
+	if err := tx.Model(app).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	history := models.ApplicationStatusHistory{
+		ApplicationID: app.ID,
+		ActorID:       actorID,
+		FromStatus:    app.Status,
+		ToStatus:      to,
+		Note:          note,
+	}
+	app.Status = to
+	return tx.Create(&history).Error
+}
+
+// addToWaitlist puts a locked application at the end of its vacancy's
+// waitlist. The caller must hold the lock on the vacancy.
+func addToWaitlist(tx *gorm.DB, app *models.Application, actorID *uuid.UUID, note string) error {
+	var last int
+	if err := tx.Model(&models.Application{}).
+		Where("vacancy_id = ? AND status = ?", app.VacancyID, models.ApplicationStatusWaitlist).
+		Select("COALESCE(MAX(waitlist_position), 0)").
+		Scan(&last).Error; err != nil {
+		return err
+	}
+
+	return transition(tx, app, models.ApplicationStatusWaitlist, actorID, note, map[string]interface{}{
+		"waitlist_position": last + 1,
+	})
+}
+
+func lockVacancy(tx *gorm.DB, id uuid.UUID) (models.Vacancy, error) {
+	var vacancy models.Vacancy
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vacancy, "id = ?", id).Error
+	return vacancy, err
+}
+
+// countSeatsTaken counts the applications holding a seat of the vacancy
+func countSeatsTaken(tx *gorm.DB, vacancyID uuid.UUID) (int64, error) {
+	var taken int64
+	err := tx.Model(&models.Application{}).
+		Where("vacancy_id = ? AND status IN ?", vacancyID, SeatHoldingStatuses).
+		Count(&taken).Error
+	return taken, err
+}
+
+func lockApplication(tx *gorm.DB, id string) (models.Application, error) {
+	var app models.Application
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&app, "id = ?", id).Error
+	return app, err
+}
+
+// Submit creates the application while holding a lock on its vacancy, so the
+// vacancy cannot be closed or expire between the check and the insert, and
+// two submits of the same applicant cannot both pass the duplicate check.
+func (r *applicationRepository) Submit(app *models.Application) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var vacancy models.Vacancy
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vacancy, "id = ?", app.VacancyID).Error; err != nil {
+			return err
+		}
+		if vacancy.Status != models.VacancyStatusApproved {
+			return ErrVacancyNotOpen
+		}
+		if vacancy.IsPastDeadline(time.Now()) {
+			return ErrDeadlinePassed
+		}
+		var existing int64
+		if err := activeApplication(tx, app.UserID, app.VacancyID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyApplied
+		}
+		if err := tx.Create(app).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.ApplicationStatusHistory{
+			ApplicationID: app.ID,
+			ActorID:       &app.UserID,
+			ToStatus:      app.Status,
+		}).Error
+	})
+}
+
+// Offer makes an offer to the applicant if the vacancy still has a free seat.
+// When the quota is full the applicant is put on the waitlist instead, and the
+// returned status tells which of the two happened. The vacancy row is locked so
+// concurrent offers are serialized.
+func (r *applicationRepository) Offer(id string, note string, expiresAt time.Time, actorID uuid.UUID) (models.ApplicationStatus, error) {
+	var result models.ApplicationStatus
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockApplication(tx, id)
+		if err != nil {
+			return err
+		}
+		if !app.Status.CanTransitionTo(models.ApplicationStatusOffered) {
+			return &InvalidTransitionError{From: app.Status, To: models.ApplicationStatusOffered}
+		}
+
+		vacancy, err := lockVacancy(tx, app.VacancyID)
+		if err != nil {
+			return err
+		}
+
+		taken, err := countSeatsTaken(tx, vacancy.ID)
+		if err != nil {
+			return err
+		}
+		if taken >= int64(vacancy.Quota) {
+			if app.Status == models.ApplicationStatusWaitlist {
+				return ErrQuotaFull
+			}
+			result = models.ApplicationStatusWaitlist
+			return addToWaitlist(tx, &app, &actorID, note)
+		}
+
+		result = models.ApplicationStatusOffered
+		return transition(tx, &app, models.ApplicationStatusOffered, &actorID, note, map[string]interface{}{
+			"offered_at":       time.Now(),
+			"offer_expires_at": expiresAt,
+		})
+	})
+	return result, err
+}
+
+// AcceptOffer confirms an open offer, making the applicant an active intern.
+// It fails with ErrActiveInternship while another internship of the
+// applicant is ongoing.
+func (r *applicationRepository) AcceptOffer(id string, actorID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockOpenOffer(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Locking the applicant serialises their accepts, so two offers
+		// accepted at once cannot both pass the check below
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&models.User{}, "id = ?", app.UserID).Error; err != nil {
+			return err
+		}
+		var ongoing int64
+		if err := ongoingInternships(tx, app.UserID).Count(&ongoing).Error; err != nil {
+			return err
+		}
+		if ongoing > 0 {
+			return ErrActiveInternship
+		}
+
+		return transition(tx, &app, models.ApplicationStatusAccepted, &actorID, "", map[string]interface{}{
+			"responded_at": time.Now(),
+		})
+	})
+}
+
+// DeclineOffer turns down an open offer, which releases its seat
+func (r *applicationRepository) DeclineOffer(id string, reason string, actorID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockOpenOffer(tx, id)
+		if err != nil {
+			return err
+		}
+
+		return transition(tx, &app, models.ApplicationStatusDeclined, &actorID, reason, map[string]interface{}{
+			"responded_at":   time.Now(),
+			"decline_reason": reason,
+		})
+	})
+}
+
+func lockOpenOffer(tx *gorm.DB, id string) (models.Application, error) {
+	app, err := lockApplication(tx, id)
+	if err != nil {
+		return app, err
+	}
+	if app.Status != models.ApplicationStatusOffered {
+		return app, ErrNoOpenOffer
+	}
+	if app.OfferExpiresAt != nil && time.Now().After(*app.OfferExpiresAt) {
+		return app, ErrOfferExpired
+	}
+	return app, nil
+}
+
+// ExpireOffers marks lapsed offers as expired and returns them with their
+// vacancy and applicant loaded, so the units can be notified.
+func (r *applicationRepository) ExpireOffers() ([]models.Application, error) {
+	var expired []models.Application
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND offer_expires_at < ?", models.ApplicationStatusOffered, time.Now()).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+
+		for i := range expired {
+			if err := transition(tx, &expired[i], models.ApplicationStatusExpired, nil, "Offer response deadline passed", nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || len(expired) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(expired))
+	for i, app := range expired {
+		ids[i] = app.ID
+	}
+	err = r.db.Preload("User").Preload("Vacancy").Where("id IN ?", ids).Find(&expired).Error
+	return expired, err
+}
+
+// PromoteWaitlisted offers the free seats of a vacancy to the head of its
+// waitlist. Applicants who meanwhile became an intern elsewhere are skipped and
+// keep their place. The promoted applications are returned with their vacancy
+// and applicant loaded.
+func (r *applicationRepository) PromoteWaitlisted(vacancyID uuid.UUID, expiresAt time.Time) ([]models.Application, error) {
+	var promoted []models.Application
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		vacancy, err := lockVacancy(tx, vacancyID)
+		if err != nil {
+			return err
+		}
+		if vacancy.Status != models.VacancyStatusApproved && vacancy.Status != models.VacancyStatusClosed {
+			return nil
+		}
+
+		taken, err := countSeatsTaken(tx, vacancy.ID)
+		if err != nil {
+			return err
+		}
+		free := int64(vacancy.Quota) - taken
+		if free <= 0 {
+			return nil
+		}
+
+		var waitlist []models.Application
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("vacancy_id = ? AND status = ?", vacancy.ID, models.ApplicationStatusWaitlist).
+			Order("waitlist_position asc").
+			Find(&waitlist).Error; err != nil {
+			return err
+		}
+
+		for i := range waitlist {
+			if int64(len(promoted)) >= free {
+				break
+			}
+			app := waitlist[i]
+
+			var internships int64
+			if err := ongoingInternships(tx, app.UserID).Count(&internships).Error; err != nil {
+				return err
+			}
+			if internships > 0 {
+				continue
+			}
+
+			if err := transition(tx, &app, models.ApplicationStatusOffered, nil, "Promoted from the waitlist", map[string]interface{}{
+				"offered_at":       time.Now(),
+				"offer_expires_at": expiresAt,
+			}); err != nil {
+				return err
+			}
+			promoted = append(promoted, app)
+		}
+		return nil
+	})
+	if err != nil || len(promoted) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(promoted))
+	for i, app := range promoted {
+		ids[i] = app.ID
+	}
+	err = r.db.Preload("User").Preload("Vacancy").Where("id IN ?", ids).Find(&promoted).Error
+	return promoted, err
+}
+
+// FindWaitlistedVacancyIDs lists the vacancies that have anyone on their waitlist
+func (r *applicationRepository) FindWaitlistedVacancyIDs() ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.Application{}).
+		Where("status = ?", models.ApplicationStatusWaitlist).
+		Distinct().
+		Pluck("vacancy_id", &ids).Error
+	return ids, err
+}
+
+func (r *applicationRepository) FindWaitlist(vacancyID string) ([]models.Application, error) {
+	var apps []models.Application
+	err := r.db.Preload("User").
+		Where("vacancy_id = ? AND status = ?", vacancyID, models.ApplicationStatusWaitlist).
+		Order("waitlist_position asc").
+		Find(&apps).Error
+	return apps, err
+}
+
+// ReorderWaitlist renumbers a vacancy's waitlist in the given order. The order
+// must name exactly the applications currently on the waitlist.
+func (r *applicationRepository) ReorderWaitlist(vacancyID uuid.UUID, order []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockVacancy(tx, vacancyID); err != nil {
+			return err
+		}
+
+		var current []uuid.UUID
+		if err := tx.Model(&models.Application{}).
+			Where("vacancy_id = ? AND status = ?", vacancyID, models.ApplicationStatusWaitlist).
+			Pluck("id", &current).Error; err != nil {
+			return err
+		}
+
+		if len(current) != len(order) {
+			return ErrWaitlistChanged
+		}
+		onList := make(map[uuid.UUID]bool, len(current))
+		for _, id := range current {
+			onList[id] = true
+		}
+		for _, id := range order {
+			if !onList[id] {
+				return ErrWaitlistChanged
+			}
+			delete(onList, id)
+		}
+
+		for i, id := range order {
+			if err := tx.Model(&models.Application{}).
+				Where("id = ?", id).
+				Update("waitlist_position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Withdraw cancels a submission that has not been decided yet, or an accepted
+// internship. Withdrawn applications no longer count towards the vacancy's
+// seats or applicants.
+func (r *applicationRepository) Withdraw(id string, reason string, actorID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockApplication(tx, id)
+		if err != nil {
+			return err
+		}
+		if !app.Status.CanTransitionTo(models.ApplicationStatusWithdrawn) {
+			return ErrNotWithdrawable
+		}
+
+		return transition(tx, &app, models.ApplicationStatusWithdrawn, &actorID, reason, map[string]interface{}{
+			"withdrawn_at":    time.Now(),
+			"withdraw_reason": reason,
+		})
+	})
+}
+
 func (r *applicationRepository) FindByUserAndVacancy(userID, vacancyID uuid.UUID) (models.Application, error) {
 	var app models.Application
-	err := r.db.Where("user_id = ? AND vacancy_id = ?", userID, vacancyID).First(&app).Error
+	err := activeApplication(r.db, userID, vacancyID).First(&app).Error
 	return app, err
 }
 
+// activeApplication selects the user's application to the vacancy. Withdrawn
+// applications do not block applying to the same vacancy again.
+func activeApplication(db *gorm.DB, userID, vacancyID uuid.UUID) *gorm.DB {
+	return db.Model(&models.Application{}).
+		Where("user_id = ? AND vacancy_id = ? AND status <> ?", userID, vacancyID, models.ApplicationStatusWithdrawn)
+}
+
 func (r *applicationRepository) FindByUserID(userID uuid.UUID, page, limit int) ([]models.Application, int64, error) {
 	var apps []models.Application
 	var total int64
 
-	query := r.db.Model(&models.Application{}).Preload("Vacancy.UnitKerja").Where("user_id = ?", userID)
+	query := r.db.Model(&models.Application{}).Preload("Vacancy.UnitKerja").Preload("Answers", orderAnswers).Preload("Documents").Where("user_id = ?", userID)
 
 	query.Count(&total)
 	err := query.Order("applied_at desc").Offset((page - 1) * limit).Limit(limit).Find(&apps).Error
 	return apps, total, err
 }
 
-func (r *applicationRepository) FindByVacancyID(vacancyID string, search string, page, limit int) ([]models.Application, int64, error) {
+func (r *applicationRepository) FindByVacancyID(vacancyID string, search string, sort string, page, limit int) ([]models.Application, int64, error) {
 	var apps []models.Application
 	var total int64
 
 	query := r.db.Model(&models.Application{}).
 		Preload("User").
+		Preload("Interview.Slot").
+		Preload("Answers", orderAnswers).
+		Preload("Documents").
 		Joins("Join users ON users.id = applications.user_id").
 		Where("vacancy_id = ?", vacancyID)
 
@@ -60,16 +501,162 @@ func (r *applicationRepository) FindByVacancyID(vacancyID string, search string,
 	}
 
 	query.Count(&total)
+
+	switch sort {
+	case "rating", "rating_desc":
+		query = query.Joins("LEFT JOIN (?) AS ratings ON ratings.application_id = applications.id", r.ratingAverages()).
+			Order("ratings.average_rating DESC NULLS LAST")
+	case "rating_asc":
+		query = query.Joins("LEFT JOIN (?) AS ratings ON ratings.application_id = applications.id", r.ratingAverages()).
+			Order("ratings.average_rating ASC NULLS LAST")
+	}
+
 	err := query.Order("applied_at desc").Offset((page - 1) * limit).Limit(limit).Find(&apps).Error
-	return apps, total, err
+	if err != nil {
+		return apps, total, err
+	}
+	return apps, total, r.attachRatings(apps)
 }
 
-func (r *applicationRepository) UpdateStatus(id string, status models.ApplicationStatus, rejectionNote string) error {
-	updates := map[string]interface{}{
-		"status":         status,
-		"rejection_note": rejectionNote,
+// ReplaceDocument attaches the document to a still submitted application,
+// replacing the one of the same type.
+func (r *applicationRepository) ReplaceDocument(appID string, doc *models.ApplicationDocument) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockApplication(tx, appID)
+		if err != nil {
+			return err
+		}
+		if app.Status != models.ApplicationStatusSubmitted {
+			return ErrNotEditable
+		}
+
+		var existing models.ApplicationDocument
+		err = tx.Where("application_id = ? AND type = ?", app.ID, doc.Type).First(&existing).Error
+		switch {
+		case err == nil:
+			doc.ID = existing.ID
+			doc.CreatedAt = existing.CreatedAt
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		doc.ApplicationID = app.ID
+		if err := tx.Save(doc).Error; err != nil {
+			return err
+		}
+
+		// The CV is also kept on the application for older clients
+		if doc.Type == models.DocumentTypeCV {
+			return tx.Model(&app).Update("cv_file_name", doc.FileName).Error
+		}
+		return nil
+	})
+}
+
+// FindAllByVacancy returns every application of a vacancy with its applicant
+// and answers, for exports
+func (r *applicationRepository) FindAllByVacancy(vacancyID string) ([]models.Application, error) {
+	var apps []models.Application
+	err := r.db.Preload("User").Preload("Answers", orderAnswers).Preload("Documents").
+		Where("vacancy_id = ?", vacancyID).
+		Order("applied_at asc").
+		Find(&apps).Error
+	return apps, err
+}
+
+func orderAnswers(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc")
+}
+
+// FindIDsByVacancy lists the applications of a vacancy matching the status and
+// applicant name/email filter, oldest first
+func (r *applicationRepository) FindIDsByVacancy(vacancyID string, status models.ApplicationStatus, search string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	query := r.db.Model(&models.Application{}).
+		Joins("Join users ON users.id = applications.user_id").
+		Where("vacancy_id = ?", vacancyID)
+
+	if status != "" {
+		query = query.Where("applications.status = ?", status)
 	}
-	return r.db.Model(&models.Application{}).Where("id = ?", id).Updates(updates).Error
+	if search != "" {
+		query = query.Where("users.name ILIKE ? OR users.email ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	err := query.Order("applied_at asc").Pluck("applications.id", &ids).Error
+	return ids, err
+}
+
+func (r *applicationRepository) ratingAverages() *gorm.DB {
+	return r.db.Model(&models.ApplicationRating{}).
+		Select("application_id, AVG(rating) AS average_rating, COUNT(*) AS rating_count").
+		Group("application_id")
+}
+
+// attachRatings fills the average reviewer rating of each application
+func (r *applicationRepository) attachRatings(apps []models.Application) error {
+	if len(apps) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(apps))
+	for i, app := range apps {
+		ids[i] = app.ID
+	}
+
+	var rows []struct {
+		ApplicationID uuid.UUID
+		AverageRating float64
+		RatingCount   int64
+	}
+	if err := r.ratingAverages().Where("application_id IN ?", ids).Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	byApplication := make(map[uuid.UUID]int, len(rows))
+	for i, row := range rows {
+		byApplication[row.ApplicationID] = i
+	}
+	for i := range apps {
+		if idx, ok := byApplication[apps[i].ID]; ok {
+			average := rows[idx].AverageRating
+			apps[i].AverageRating = &average
+			apps[i].RatingCount = rows[idx].RatingCount
+		}
+	}
+	return nil
+}
+
+func (r *applicationRepository) UpdateStatus(id string, status models.ApplicationStatus, note string, actorID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockApplication(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if status == models.ApplicationStatusWaitlist && app.Status.CanTransitionTo(status) {
+			if _, err := lockVacancy(tx, app.VacancyID); err != nil {
+				return err
+			}
+			return addToWaitlist(tx, &app, &actorID, note)
+		}
+
+		var updates map[string]interface{}
+		if status == models.ApplicationStatusRejected {
+			updates = map[string]interface{}{"rejection_note": note}
+		}
+		return transition(tx, &app, status, &actorID, note, updates)
+	})
+}
+
+func (r *applicationRepository) FindStatusHistory(appID string) ([]models.ApplicationStatusHistory, error) {
+	var history []models.ApplicationStatusHistory
+	err := r.db.Preload("Actor").
+		Where("application_id = ?", appID).
+		Order("created_at asc").
+		Find(&history).Error
+	return history, err
 }
 
 func (r *applicationRepository) FindByID(id string) (models.Application, error) {
@@ -78,18 +665,55 @@ func (r *applicationRepository) FindByID(id string) (models.Application, error)
 	return app, err
 }
 
+// FindWithFiles loads the application with its answers and documents, for
+// looking up the files attached to it
+func (r *applicationRepository) FindWithFiles(id string) (models.Application, error) {
+	var app models.Application
+	err := r.db.Preload("Vacancy").Preload("Answers", orderAnswers).Preload("Documents").First(&app, "id = ?", id).Error
+	return app, err
+}
+
 func (r *applicationRepository) CountAcceptedByUser(userID uuid.UUID) (int64, error) {
 	var count int64
-	// Check if user has an accepted application for a vacancy that is still ongoing
-	// "Ongoing" is defined as: Current time is before (Deadline + DurationMonths)
-	err := r.db.Model(&models.Application{}).
+	err := ongoingInternships(r.db, userID).Count(&count).Error
+	return count, err
+}
+
+// ongoingInternships selects the user's accepted applications for a vacancy that is still ongoing.
+// "Ongoing" is defined as: Current time is before (Deadline + DurationMonths)
+func ongoingInternships(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Model(&models.Application{}).
 		Joins("JOIN vacancies ON vacancies.id = applications.vacancy_id").
 		Where("applications.user_id = ? AND applications.status = ?", userID, models.ApplicationStatusAccepted).
-		Where("(vacancies.deadline + (vacancies.duration_months * INTERVAL '1 month')) > NOW()").
-		Count(&count).Error
-	return count, err
+		Where("(vacancies.deadline + (vacancies.duration_months * INTERVAL '1 month')) > NOW()")
 }
 
 func (r *applicationRepository) Update(app *models.Application) error {
 	return r.db.Save(app).Error
 }
+
+// FinishEnded moves accepted applications whose internship period is over to finished
+func (r *applicationRepository) FinishEnded() (int64, error) {
+	var affected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		ended := tx.Model(&models.Vacancy{}).
+			Select("id").
+			Where("(deadline + (duration_months * INTERVAL '1 month')) <= NOW()")
+
+		var apps []models.Application
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND vacancy_id IN (?)", models.ApplicationStatusAccepted, ended).
+			Find(&apps).Error; err != nil {
+			return err
+		}
+
+		for i := range apps {
+			if err := transition(tx, &apps[i], models.ApplicationStatusFinished, nil, "Internship period ended", nil); err != nil {
+				return err
+			}
+		}
+		affected = int64(len(apps))
+		return nil
+	})
+	return affected, err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ApplicationReviewRepository interface {
+	SaveRating(rating *models.ApplicationRating) error
+	FindRatings(appID uuid.UUID) ([]models.ApplicationRating, error)
+	DeleteRating(appID, reviewerID uuid.UUID) error
+	CreateComment(comment *models.ApplicationComment) error
+	FindComments(appID uuid.UUID) ([]models.ApplicationComment, error)
+	FindCommentByID(id string) (models.ApplicationComment, error)
+	DeleteComment(id string) error
+}
+
+type applicationReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewApplicationReviewRepository(db *gorm.DB) ApplicationReviewRepository {
+	return &applicationReviewRepository{db: db}
+}
+
+// SaveRating stores the reviewer's rating, replacing their earlier one
+func (r *applicationReviewRepository) SaveRating(rating *models.ApplicationRating) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "application_id"}, {Name: "reviewer_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rating", "updated_at"}),
+	}).Create(rating).Error
+	if err != nil {
+		return err
+	}
+	// On conflict the existing row keeps its own ID, so reload it
+	var saved models.ApplicationRating
+	err = r.db.Preload("Reviewer").
+		Where("application_id = ? AND reviewer_id = ?", rating.ApplicationID, rating.ReviewerID).
+		First(&saved).Error
+	if err != nil {
+		return err
+	}
+	*rating = saved
+	return nil
+}
+
+func (r *applicationReviewRepository) FindRatings(appID uuid.UUID) ([]models.ApplicationRating, error) {
+	var ratings []models.ApplicationRating
+	err := r.db.Preload("Reviewer").Where("application_id = ?", appID).Order("created_at asc").Find(&ratings).Error
+	return ratings, err
+}
+
+func (r *applicationReviewRepository) DeleteRating(appID, reviewerID uuid.UUID) error {
+	result := r.db.Unscoped().Where("application_id = ? AND reviewer_id = ?", appID, reviewerID).Delete(&models.ApplicationRating{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *applicationReviewRepository) CreateComment(comment *models.ApplicationComment) error {
+	if err := r.db.Create(comment).Error; err != nil {
+		return err
+	}
+	return r.db.Preload("Author").First(comment, "id = ?", comment.ID).Error
+}
+
+func (r *applicationReviewRepository) FindComments(appID uuid.UUID) ([]models.ApplicationComment, error) {
+	var comments []models.ApplicationComment
+	err := r.db.Preload("Author").Where("application_id = ?", appID).Order("created_at asc").Find(&comments).Error
+	return comments, err
+}
+
+func (r *applicationReviewRepository) FindCommentByID(id string) (models.ApplicationComment, error) {
+	var comment models.ApplicationComment
+	err := r.db.First(&comment, "id = ?", id).Error
+	return comment, err
+}
+
+func (r *applicationReviewRepository) DeleteComment(id string) error {
+	return r.db.Delete(&models.ApplicationComment{}, "id = ?", id).Error
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/dr15/internship-hub-api/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AttendanceRepository interface {
@@ -14,8 +15,10 @@ type AttendanceRepository interface {
 	FindByID(id string) (models.Attendance, error)
 	FindTodayByUser(userID uuid.UUID) (models.Attendance, error)
 	FindByUserID(userID uuid.UUID, page, limit int) ([]models.Attendance, int64, error)
+	FindByApplicationID(appID uuid.UUID, page, limit int) ([]models.Attendance, int64, error)
 	FindAllWithFilters(search string, unitID *uuid.UUID, startDate, endDate string, page, limit int) ([]models.Attendance, int64, error)
 	GetRecap(unitID *uuid.UUID, startDate, endDate string) ([]models.Attendance, error)
+	MarkAbsent(day time.Time) (int64, error)
 }
 
 type attendanceRepository struct {
@@ -58,6 +61,17 @@ func (r *attendanceRepository) FindByUserID(userID uuid.UUID, page, limit int) (
 	return attendances, total, err
 }
 
+func (r *attendanceRepository) FindByApplicationID(appID uuid.UUID, page, limit int) ([]models.Attendance, int64, error) {
+	var attendances []models.Attendance
+	var total int64
+
+	query := r.db.Model(&models.Attendance{}).Where("application_id = ?", appID)
+	query.Count(&total)
+
+	err := query.Order("date desc").Offset((page - 1) * limit).Limit(limit).Find(&attendances).Error
+	return attendances, total, err
+}
+
 func (r *attendanceRepository) FindAllWithFilters(search string, unitID *uuid.UUID, startDate, endDate string, page, limit int) ([]models.Attendance, int64, error) {
 	var attendances []models.Attendance
 	var total int64
@@ -108,3 +122,37 @@ func (r *attendanceRepository) GetRecap(unitID *uuid.UUID, startDate, endDate st
 	err := query.Order("users.name asc, attendances.date asc").Find(&attendances).Error
 	return attendances, err
 }
+
+// MarkAbsent records an alpha attendance for every active intern who has no
+// attendance on the given working day.
+func (r *attendanceRepository) MarkAbsent(day time.Time) (int64, error) {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return 0, nil
+	}
+	date := day.Format("2006-01-02")
+
+	var apps []models.Application
+	err := r.db.Model(&models.Application{}).
+		Joins("JOIN vacancies ON vacancies.id = applications.vacancy_id").
+		Where("applications.status = ?", models.ApplicationStatusAccepted).
+		Where("vacancies.deadline::date <= ? AND (vacancies.deadline + (vacancies.duration_months * INTERVAL '1 month'))::date > ?", date, date).
+		Where("NOT EXISTS (SELECT 1 FROM attendances WHERE attendances.user_id = applications.user_id AND attendances.date = ? AND attendances.deleted_at IS NULL)", date).
+		Find(&apps).Error
+	if err != nil || len(apps) == 0 {
+		return 0, err
+	}
+
+	absences := make([]models.Attendance, len(apps))
+	for i, app := range apps {
+		absences[i] = models.Attendance{
+			UserID:        app.UserID,
+			ApplicationID: app.ID,
+			Date:          day,
+			Status:        models.AttendanceStatusAlpha,
+			Notes:         "Tidak ada presensi (ditandai otomatis)",
+		}
+	}
+
+	result := r.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&absences)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuthThrottleRepository interface {
+	FindByKeys(keys []string) ([]models.AuthThrottle, error)
+	RecordFailure(key string, window time.Duration) (models.AuthThrottle, error)
+	Lock(key string, until time.Time) (bool, error)
+	Reset(keys ...string) error
+	DeleteStale(olderThan time.Time) (int64, error)
+}
+
+type authThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthThrottleRepository(db *gorm.DB) AuthThrottleRepository {
+	return &authThrottleRepository{db: db}
+}
+
+func (r *authThrottleRepository) FindByKeys(keys []string) ([]models.AuthThrottle, error) {
+	var throttles []models.AuthThrottle
+	err := r.db.Where("key IN ?", keys).Find(&throttles).Error
+	return throttles, err
+}
+
+// RecordFailure counts one more failure for the key and returns the updated
+// counter. Failures older than the window no longer count, so the count
+// starts over after a quiet period.
+func (r *authThrottleRepository) RecordFailure(key string, window time.Duration) (models.AuthThrottle, error) {
+	var throttle models.AuthThrottle
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// Make sure the row exists, then lock it so concurrent failures on
+		// other replicas are counted one after another
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "key"}}, DoNothing: true}).
+			Create(&models.AuthThrottle{Key: key, LastFailureAt: now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).First(&throttle).Error; err != nil {
+			return err
+		}
+
+		if throttle.Failures > 0 && now.Sub(throttle.LastFailureAt) > window &&
+			(throttle.LockedUntil == nil || now.After(*throttle.LockedUntil)) {
+			throttle.Failures = 0
+			throttle.LockedUntil = nil
+		}
+		throttle.Failures++
+		throttle.LastFailureAt = now
+		return tx.Model(&throttle).Updates(map[string]interface{}{
+			"failures":        throttle.Failures,
+			"last_failure_at": throttle.LastFailureAt,
+			"locked_until":    throttle.LockedUntil,
+		}).Error
+	})
+	return throttle, err
+}
+
+// Lock blocks the key until the given time. It reports whether the key was
+// newly locked, so a lockout is only announced once.
+func (r *authThrottleRepository) Lock(key string, until time.Time) (bool, error) {
+	result := r.db.Model(&models.AuthThrottle{}).
+		Where("key = ? AND (locked_until IS NULL OR locked_until < ?)", key, time.Now()).
+		Update("locked_until", until)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Reset clears the counters of the keys, ending any lockout
+func (r *authThrottleRepository) Reset(keys ...string) error {
+	return r.db.Unscoped().Where("key IN ?", keys).Delete(&models.AuthThrottle{}).Error
+}
+
+// DeleteStale removes counters with no recent failures and no active lockout
+func (r *authThrottleRepository) DeleteStale(olderThan time.Time) (int64, error) {
+	result := r.db.Unscoped().
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", olderThan, time.Now()).
+		Delete(&models.AuthThrottle{})
+	return result.RowsAffected, result.Error
+}
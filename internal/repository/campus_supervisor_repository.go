@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CampusSupervisorRepository interface {
+	Create(supervisor *models.CampusSupervisor) error
+	Update(supervisor *models.CampusSupervisor) error
+	FindByID(id string) (models.CampusSupervisor, error)
+	FindByToken(token string) (models.CampusSupervisor, error)
+	FindActive(appID uuid.UUID, email string) (models.CampusSupervisor, error)
+	FindByApplication(appID uuid.UUID) ([]models.CampusSupervisor, error)
+	CountActive(appID uuid.UUID) (int64, error)
+	FindSupervised(supervisorID uuid.UUID) ([]models.CampusSupervisor, error)
+	FindAccess(appID, supervisorID uuid.UUID) (models.CampusSupervisor, error)
+}
+
+type campusSupervisorRepository struct {
+	db *gorm.DB
+}
+
+func NewCampusSupervisorRepository(db *gorm.DB) CampusSupervisorRepository {
+	return &campusSupervisorRepository{db: db}
+}
+
+func (r *campusSupervisorRepository) Create(supervisor *models.CampusSupervisor) error {
+	return r.db.Omit(clause.Associations).Create(supervisor).Error
+}
+
+func (r *campusSupervisorRepository) Update(supervisor *models.CampusSupervisor) error {
+	return r.db.Omit(clause.Associations).Save(supervisor).Error
+}
+
+func (r *campusSupervisorRepository) FindByID(id string) (models.CampusSupervisor, error) {
+	var supervisor models.CampusSupervisor
+	err := r.db.First(&supervisor, "id = ?", id).Error
+	return supervisor, err
+}
+
+// FindByToken loads an invitation with what the invitee needs to recognise it
+func (r *campusSupervisorRepository) FindByToken(token string) (models.CampusSupervisor, error) {
+	var supervisor models.CampusSupervisor
+	err := r.db.Preload("Application.User").Preload("Application.Vacancy.UnitKerja").
+		First(&supervisor, "token = ?", token).Error
+	return supervisor, err
+}
+
+// FindActive returns the invitation or access of an email address to an
+// application that has not been revoked
+func (r *campusSupervisorRepository) FindActive(appID uuid.UUID, email string) (models.CampusSupervisor, error) {
+	var supervisor models.CampusSupervisor
+	err := r.db.Where("application_id = ? AND LOWER(email) = LOWER(?) AND revoked_at IS NULL", appID, email).
+		First(&supervisor).Error
+	return supervisor, err
+}
+
+func (r *campusSupervisorRepository) FindByApplication(appID uuid.UUID) ([]models.CampusSupervisor, error) {
+	var supervisors []models.CampusSupervisor
+	err := r.db.Where("application_id = ? AND revoked_at IS NULL", appID).Order("created_at").Find(&supervisors).Error
+	return supervisors, err
+}
+
+func (r *campusSupervisorRepository) CountActive(appID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CampusSupervisor{}).Where("application_id = ? AND revoked_at IS NULL", appID).Count(&count).Error
+	return count, err
+}
+
+// FindSupervised lists the internships a supervisor has accepted access to
+func (r *campusSupervisorRepository) FindSupervised(supervisorID uuid.UUID) ([]models.CampusSupervisor, error) {
+	var supervisors []models.CampusSupervisor
+	err := r.db.Preload("Application.User").Preload("Application.Vacancy.UnitKerja").
+		Where("supervisor_id = ? AND accepted_at IS NOT NULL AND revoked_at IS NULL", supervisorID).
+		Order("accepted_at desc").
+		Find(&supervisors).Error
+	return supervisors, err
+}
+
+// FindAccess returns the supervisor's accepted access to one application
+func (r *campusSupervisorRepository) FindAccess(appID, supervisorID uuid.UUID) (models.CampusSupervisor, error) {
+	var supervisor models.CampusSupervisor
+	err := r.db.Preload("Application.User").Preload("Application.Vacancy.UnitKerja").
+		Where("application_id = ? AND supervisor_id = ? AND accepted_at IS NOT NULL AND revoked_at IS NULL", appID, supervisorID).
+		First(&supervisor).Error
+	return supervisor, err
+}
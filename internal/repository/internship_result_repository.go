@@ -4,11 +4,13 @@ import (
 	"github.com/dr15/internship-hub-api/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type InternshipResultRepository interface {
 	Create(result *models.InternshipResult) error
 	Update(result *models.InternshipResult) error
+	SaveReview(result *models.InternshipResult, reviewerID uuid.UUID) error
 	FindByApplicationID(appID uuid.UUID) (*models.InternshipResult, error)
 	FindByUserID(userID uuid.UUID) ([]models.InternshipResult, error)
 	FindAllPendingReview(unitKerjaID uuid.UUID, search string, page, limit int) ([]models.InternshipResult, int64, error)
@@ -30,9 +32,25 @@ func (r *internshipResultRepository) Update(result *models.InternshipResult) err
 	return r.db.Save(result).Error
 }
 
+// SaveReview stores the reviewed result and completes its application in one
+// transaction. It fails with an InvalidTransitionError, saving nothing, when
+// the application cannot be completed, for instance because it already was.
+func (r *internshipResultRepository) SaveReview(result *models.InternshipResult, reviewerID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockApplication(tx, result.ApplicationID.String())
+		if err != nil {
+			return err
+		}
+		if err := transition(tx, &app, models.ApplicationStatusCompleted, &reviewerID, "Internship reviewed", nil); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(result).Error
+	})
+}
+
 func (r *internshipResultRepository) FindByApplicationID(appID uuid.UUID) (*models.InternshipResult, error) {
 	var result models.InternshipResult
-	err := r.db.Preload("Application.Vacancy.UnitKerja").Preload("User").First(&result, "application_id = ?", appID).Error
+	err := r.db.Preload("Application.Vacancy.UnitKerja").Preload("User").Preload("MentorEvaluation.Mentor").First(&result, "application_id = ?", appID).Error
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +70,7 @@ func (r *internshipResultRepository) FindAllPendingReview(unitKerjaID uuid.UUID,
 	query := r.db.Model(&models.InternshipResult{}).
 		Preload("Application.Vacancy.UnitKerja").
 		Preload("User").
+		Preload("MentorEvaluation.Mentor").
 		Joins("Join applications ON applications.id = internship_results.application_id").
 		Joins("Join vacancies ON vacancies.id = applications.vacancy_id")
 
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSlotFull        = errors.New("interview slot is fully booked")
+	ErrSlotUnavailable = errors.New("interview slot is not available for this application")
+	ErrSlotBooked      = errors.New("interview slot already has bookings")
+	ErrNotInvited      = errors.New("application has no open interview invitation")
+	ErrNotScheduled    = errors.New("interview has not been scheduled")
+)
+
+type InterviewRepository interface {
+	CreateSlot(slot *models.InterviewSlot) error
+	FindSlotByID(id string) (models.InterviewSlot, error)
+	FindSlotsByVacancy(vacancyID uuid.UUID, upcomingOnly bool) ([]models.InterviewSlot, error)
+	DeleteSlot(id string) error
+	Invite(appID uuid.UUID, invitedBy uuid.UUID) (*models.Interview, error)
+	Book(appID uuid.UUID, slotID uuid.UUID) (*models.Interview, error)
+	RecordOutcome(appID uuid.UUID, outcome models.InterviewOutcome, notes string, reviewerID uuid.UUID) (*models.Interview, error)
+	FindByApplicationID(appID uuid.UUID) (*models.Interview, error)
+}
+
+type interviewRepository struct {
+	db *gorm.DB
+}
+
+func NewInterviewRepository(db *gorm.DB) InterviewRepository {
+	return &interviewRepository{db: db}
+}
+
+func (r *interviewRepository) CreateSlot(slot *models.InterviewSlot) error {
+	return r.db.Create(slot).Error
+}
+
+func (r *interviewRepository) FindSlotByID(id string) (models.InterviewSlot, error) {
+	var slot models.InterviewSlot
+	err := r.db.First(&slot, "id = ?", id).Error
+	return slot, err
+}
+
+func (r *interviewRepository) FindSlotsByVacancy(vacancyID uuid.UUID, upcomingOnly bool) ([]models.InterviewSlot, error) {
+	var slots []models.InterviewSlot
+	query := r.db.Where("vacancy_id = ?", vacancyID)
+	if upcomingOnly {
+		query = query.Where("starts_at > ?", time.Now())
+	}
+	if err := query.Order("starts_at asc").Find(&slots).Error; err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return slots, nil
+	}
+
+	ids := make([]uuid.UUID, len(slots))
+	for i, slot := range slots {
+		ids[i] = slot.ID
+	}
+
+	var rows []struct {
+		SlotID uuid.UUID
+		Booked int64
+	}
+	err := r.db.Model(&models.Interview{}).
+		Select("slot_id, COUNT(*) AS booked").
+		Where("slot_id IN ? AND status = ?", ids, models.InterviewStatusScheduled).
+		Group("slot_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	booked := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		booked[row.SlotID] = row.Booked
+	}
+	for i := range slots {
+		slots[i].BookedCount = booked[slots[i].ID]
+	}
+	return slots, nil
+}
+
+// DeleteSlot removes a slot that nobody has booked yet
+func (r *interviewRepository) DeleteSlot(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var slot models.InterviewSlot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		var booked int64
+		if err := tx.Model(&models.Interview{}).Where("slot_id = ?", slot.ID).Count(&booked).Error; err != nil {
+			return err
+		}
+		if booked > 0 {
+			return ErrSlotBooked
+		}
+		return tx.Delete(&slot).Error
+	})
+}
+
+// Invite moves the application to the interview stage and opens an invitation
+// the applicant can book a slot for.
+func (r *interviewRepository) Invite(appID uuid.UUID, invitedBy uuid.UUID) (*models.Interview, error) {
+	interview := models.Interview{
+		ApplicationID: appID,
+		Status:        models.InterviewStatusInvited,
+		InvitedBy:     invitedBy,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockApplication(tx, appID.String())
+		if err != nil {
+			return err
+		}
+		if err := transition(tx, &app, models.ApplicationStatusInterview, &invitedBy, "Invited to interview", nil); err != nil {
+			return err
+		}
+		return tx.Create(&interview).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &interview, nil
+}
+
+// Book assigns the application's interview to a slot, or moves it to another
+// slot when it is already booked. The slot is locked while its capacity is checked.
+func (r *interviewRepository) Book(appID uuid.UUID, slotID uuid.UUID) (*models.Interview, error) {
+	var interview models.Interview
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&interview, "application_id = ?", appID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotInvited
+			}
+			return err
+		}
+		if interview.Status != models.InterviewStatusInvited && interview.Status != models.InterviewStatusScheduled {
+			return ErrNotInvited
+		}
+
+		var app models.Application
+		if err := tx.First(&app, "id = ?", appID).Error; err != nil {
+			return err
+		}
+
+		var slot models.InterviewSlot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, "id = ?", slotID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSlotUnavailable
+			}
+			return err
+		}
+		if slot.VacancyID != app.VacancyID || !slot.StartsAt.After(time.Now()) {
+			return ErrSlotUnavailable
+		}
+
+		var booked int64
+		if err := tx.Model(&models.Interview{}).
+			Where("slot_id = ? AND status = ? AND id <> ?", slot.ID, models.InterviewStatusScheduled, interview.ID).
+			Count(&booked).Error; err != nil {
+			return err
+		}
+		if booked >= int64(slot.Capacity) {
+			return ErrSlotFull
+		}
+
+		interview.SlotID = &slot.ID
+		interview.Slot = &slot
+		interview.Status = models.InterviewStatusScheduled
+		interview.Sequence++
+		return tx.Model(&interview).Updates(map[string]interface{}{
+			"slot_id":  slot.ID,
+			"status":   interview.Status,
+			"sequence": interview.Sequence,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &interview, nil
+}
+
+// RecordOutcome completes a booked interview. The interview is locked so a
+// concurrent booking cannot slip in between the check and the update.
+func (r *interviewRepository) RecordOutcome(appID uuid.UUID, outcome models.InterviewOutcome, notes string, reviewerID uuid.UUID) (*models.Interview, error) {
+	var interview models.Interview
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&interview, "application_id = ?", appID).Error; err != nil {
+			return err
+		}
+		if interview.Status != models.InterviewStatusScheduled {
+			return ErrNotScheduled
+		}
+
+		interview.Status = models.InterviewStatusCompleted
+		interview.Outcome = outcome
+		interview.InterviewerNotes = notes
+		interview.ReviewedBy = &reviewerID
+		return tx.Model(&interview).Updates(map[string]interface{}{
+			"status":            interview.Status,
+			"outcome":           interview.Outcome,
+			"interviewer_notes": interview.InterviewerNotes,
+			"reviewed_by":       interview.ReviewedBy,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &interview, nil
+}
+
+func (r *interviewRepository) FindByApplicationID(appID uuid.UUID) (*models.Interview, error) {
+	var interview models.Interview
+	if err := r.db.Preload("Slot").First(&interview, "application_id = ?", appID).Error; err != nil {
+		return nil, err
+	}
+	return &interview, nil
+}
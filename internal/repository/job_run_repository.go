@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"github.com/dr15/internship-hub-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type JobRunRepository interface {
+	Create(run *models.JobRun) error
+	Update(run *models.JobRun) error
+	FindAll(name string, status string, page, limit int) ([]models.JobRun, int64, error)
+}
+
+type jobRunRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRunRepository(db *gorm.DB) JobRunRepository {
+	return &jobRunRepository{db: db}
+}
+
+func (r *jobRunRepository) Create(run *models.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *jobRunRepository) Update(run *models.JobRun) error {
+	return r.db.Save(run).Error
+}
+
+func (r *jobRunRepository) FindAll(name string, status string, page, limit int) ([]models.JobRun, int64, error) {
+	var runs []models.JobRun
+	var total int64
+
+	query := r.db.Model(&models.JobRun{})
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+	err := query.Order("started_at desc").Offset((page - 1) * limit).Limit(limit).Find(&runs).Error
+	return runs, total, err
+}
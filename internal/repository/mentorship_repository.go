@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MentorshipRepository interface {
+	SetMentor(appID uuid.UUID, mentorID *uuid.UUID) error
+	FindMentees(mentorID uuid.UUID) ([]models.Application, error)
+
+	FindLogbookEntry(appID uuid.UUID, date time.Time) (models.LogbookEntry, error)
+	FindLogbookEntryByID(id string) (models.LogbookEntry, error)
+	FindLogbook(appID uuid.UUID, page, limit int) ([]models.LogbookEntry, int64, error)
+	SaveLogbookEntry(entry *models.LogbookEntry) error
+
+	CreateLeaveRequest(leave *models.LeaveRequest) error
+	FindLeaveRequestByID(id string) (models.LeaveRequest, error)
+	FindLeaveRequests(appID uuid.UUID) ([]models.LeaveRequest, error)
+	HasOverlappingLeave(appID uuid.UUID, start, end time.Time) (bool, error)
+	UpdateLeaveRequest(leave *models.LeaveRequest) error
+	ApproveLeaveRequest(leave *models.LeaveRequest) error
+
+	FindEvaluation(appID uuid.UUID) (models.MentorEvaluation, error)
+	SaveEvaluation(evaluation *models.MentorEvaluation) error
+}
+
+type mentorshipRepository struct {
+	db *gorm.DB
+}
+
+func NewMentorshipRepository(db *gorm.DB) MentorshipRepository {
+	return &mentorshipRepository{db: db}
+}
+
+// SetMentor assigns a mentor to an application, or removes it when mentorID is nil
+func (r *mentorshipRepository) SetMentor(appID uuid.UUID, mentorID *uuid.UUID) error {
+	return r.db.Model(&models.Application{}).Where("id = ?", appID).Update("mentor_id", mentorID).Error
+}
+
+// FindMentees lists the internships a mentor supervises, current ones first
+func (r *mentorshipRepository) FindMentees(mentorID uuid.UUID) ([]models.Application, error) {
+	var apps []models.Application
+	err := r.db.Preload("User").Preload("Vacancy.UnitKerja").
+		Where("mentor_id = ? AND status IN ?", mentorID, []models.ApplicationStatus{
+			models.ApplicationStatusAccepted, models.ApplicationStatusFinished, models.ApplicationStatusCompleted,
+		}).
+		Order("CASE WHEN status = 'accepted' THEN 0 ELSE 1 END, applied_at DESC").
+		Find(&apps).Error
+	return apps, err
+}
+
+func (r *mentorshipRepository) FindLogbookEntry(appID uuid.UUID, date time.Time) (models.LogbookEntry, error) {
+	var entry models.LogbookEntry
+	err := r.db.Where("application_id = ? AND date = ?", appID, date.Format("2006-01-02")).First(&entry).Error
+	return entry, err
+}
+
+func (r *mentorshipRepository) FindLogbookEntryByID(id string) (models.LogbookEntry, error) {
+	var entry models.LogbookEntry
+	err := r.db.First(&entry, "id = ?", id).Error
+	return entry, err
+}
+
+func (r *mentorshipRepository) FindLogbook(appID uuid.UUID, page, limit int) ([]models.LogbookEntry, int64, error) {
+	var entries []models.LogbookEntry
+	var total int64
+
+	query := r.db.Model(&models.LogbookEntry{}).Where("application_id = ?", appID)
+	query.Count(&total)
+
+	err := query.Order("date desc").Offset((page - 1) * limit).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+func (r *mentorshipRepository) SaveLogbookEntry(entry *models.LogbookEntry) error {
+	return r.db.Save(entry).Error
+}
+
+func (r *mentorshipRepository) CreateLeaveRequest(leave *models.LeaveRequest) error {
+	return r.db.Create(leave).Error
+}
+
+func (r *mentorshipRepository) FindLeaveRequestByID(id string) (models.LeaveRequest, error) {
+	var leave models.LeaveRequest
+	err := r.db.First(&leave, "id = ?", id).Error
+	return leave, err
+}
+
+func (r *mentorshipRepository) FindLeaveRequests(appID uuid.UUID) ([]models.LeaveRequest, error) {
+	var leaves []models.LeaveRequest
+	err := r.db.Where("application_id = ?", appID).Order("start_date desc").Find(&leaves).Error
+	return leaves, err
+}
+
+// HasOverlappingLeave reports whether a pending or approved request already
+// covers part of the range
+func (r *mentorshipRepository) HasOverlappingLeave(appID uuid.UUID, start, end time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.LeaveRequest{}).
+		Where("application_id = ? AND status IN ?", appID, []models.ApprovalStatus{models.ApprovalStatusPending, models.ApprovalStatusApproved}).
+		Where("start_date <= ? AND end_date >= ?", end.Format("2006-01-02"), start.Format("2006-01-02")).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *mentorshipRepository) UpdateLeaveRequest(leave *models.LeaveRequest) error {
+	return r.db.Omit(clause.Associations).Save(leave).Error
+}
+
+// ApproveLeaveRequest saves the approval and records each working day of the
+// leave in the attendance. Days already marked absent become leave; days
+// the intern checked in anyway are left alone, and a check-in on a leave day
+// later turns it into a present one.
+func (r *mentorshipRepository) ApproveLeaveRequest(leave *models.LeaveRequest) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(leave).Error; err != nil {
+			return err
+		}
+
+		var days []models.Attendance
+		for day := leave.StartDate; !day.After(leave.EndDate); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+				continue
+			}
+			days = append(days, models.Attendance{
+				UserID:        leave.UserID,
+				ApplicationID: leave.ApplicationID,
+				Date:          day,
+				Status:        models.AttendanceStatusLeave,
+				Notes:         "Izin: " + leave.Reason,
+			})
+		}
+		if len(days) == 0 {
+			return nil
+		}
+		return tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "notes", "updated_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Eq{Column: clause.Column{Table: "attendances", Name: "status"}, Value: models.AttendanceStatusAlpha},
+			}},
+		}).Create(&days).Error
+	})
+}
+
+func (r *mentorshipRepository) FindEvaluation(appID uuid.UUID) (models.MentorEvaluation, error) {
+	var evaluation models.MentorEvaluation
+	err := r.db.Where("application_id = ?", appID).First(&evaluation).Error
+	return evaluation, err
+}
+
+// SaveEvaluation stores the mentor's scores, replacing earlier input for the same internship
+func (r *mentorshipRepository) SaveEvaluation(evaluation *models.MentorEvaluation) error {
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "application_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mentor_id", "performance_score", "discipline_score", "notes", "updated_at"}),
+	}).Create(evaluation).Error
+}
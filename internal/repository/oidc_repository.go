@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrOIDCStateInvalid = errors.New("sign-in attempt is invalid, expired or already used")
+
+type OIDCRepository interface {
+	CreateState(state *models.OIDCLoginState) error
+	ConsumeState(state string) (models.OIDCLoginState, error)
+	SetLoginCode(id uuid.UUID, userID uuid.UUID, codeHash string) error
+	ExchangeLoginCode(codeHash string) (uuid.UUID, error)
+	DeleteExpired() (int64, error)
+	FindMappings() ([]models.OIDCRoleMapping, error)
+	FindMappingByID(id string) (models.OIDCRoleMapping, error)
+	CreateMapping(mapping *models.OIDCRoleMapping) error
+	UpdateMapping(mapping *models.OIDCRoleMapping) error
+	DeleteMapping(id string) error
+}
+
+type oidcRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) OIDCRepository {
+	return &oidcRepository{db: db}
+}
+
+func (r *oidcRepository) CreateState(state *models.OIDCLoginState) error {
+	return r.db.Create(state).Error
+}
+
+// ConsumeState marks an unexpired attempt as used so the callback can only
+// complete it once
+func (r *oidcRepository) ConsumeState(state string) (models.OIDCLoginState, error) {
+	var attempt models.OIDCLoginState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("state = ?", state).First(&attempt).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOIDCStateInvalid
+			}
+			return err
+		}
+		if attempt.UsedAt != nil || time.Now().After(attempt.ExpiresAt) {
+			return ErrOIDCStateInvalid
+		}
+		now := time.Now()
+		attempt.UsedAt = &now
+		return tx.Model(&attempt).Update("used_at", now).Error
+	})
+	return attempt, err
+}
+
+// SetLoginCode records who signed in so the frontend can trade the code for tokens
+func (r *oidcRepository) SetLoginCode(id uuid.UUID, userID uuid.UUID, codeHash string) error {
+	return r.db.Model(&models.OIDCLoginState{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"user_id": userID, "login_code": codeHash}).Error
+}
+
+// ExchangeLoginCode consumes an unexpired login code and returns its user
+func (r *oidcRepository) ExchangeLoginCode(codeHash string) (uuid.UUID, error) {
+	var attempt models.OIDCLoginState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("login_code = ? AND user_id IS NOT NULL", codeHash).First(&attempt).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOIDCStateInvalid
+			}
+			return err
+		}
+		if attempt.ExchangedAt != nil || time.Now().After(attempt.ExpiresAt) {
+			return ErrOIDCStateInvalid
+		}
+		return tx.Model(&attempt).Update("exchanged_at", time.Now()).Error
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return *attempt.UserID, nil
+}
+
+func (r *oidcRepository) DeleteExpired() (int64, error) {
+	result := r.db.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *oidcRepository) FindMappings() ([]models.OIDCRoleMapping, error) {
+	var mappings []models.OIDCRoleMapping
+	err := r.db.Preload("UnitKerja").Order("priority ASC, created_at ASC").Find(&mappings).Error
+	return mappings, err
+}
+
+func (r *oidcRepository) FindMappingByID(id string) (models.OIDCRoleMapping, error) {
+	var mapping models.OIDCRoleMapping
+	err := r.db.Preload("UnitKerja").First(&mapping, "id = ?", id).Error
+	return mapping, err
+}
+
+func (r *oidcRepository) CreateMapping(mapping *models.OIDCRoleMapping) error {
+	return r.db.Create(mapping).Error
+}
+
+func (r *oidcRepository) UpdateMapping(mapping *models.OIDCRoleMapping) error {
+	return r.db.Omit("UnitKerja").Save(mapping).Error
+}
+
+func (r *oidcRepository) DeleteMapping(id string) error {
+	return r.db.Delete(&models.OIDCRoleMapping{}, "id = ?", id).Error
+}
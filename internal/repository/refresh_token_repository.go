@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrTokenReused  = errors.New("refresh token was already used")
+)
+
+type RefreshTokenRepository interface {
+	Rotate(tokenHash string, next *models.RefreshToken) (models.RefreshToken, error)
+	DeleteExpired() (int64, error)
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Rotate consumes the token with the given hash and stores next as its
+// successor in the same family, returning the consumed token. A token that
+// was already rotated is being replayed, so its whole family is revoked and
+// ErrTokenReused is returned.
+func (r *refreshTokenRepository) Rotate(tokenHash string, next *models.RefreshToken) (models.RefreshToken, error) {
+	var current models.RefreshToken
+	reused := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTokenInvalid
+			}
+			return err
+		}
+		if current.RevokedAt != nil || time.Now().After(current.ExpiresAt) {
+			return ErrTokenInvalid
+		}
+		if current.RotatedAt != nil {
+			reused = true
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&current).Update("rotated_at", now).Error; err != nil {
+			return err
+		}
+		next.UserID = current.UserID
+		next.FamilyID = current.FamilyID
+		return tx.Create(next).Error
+	})
+	if err != nil {
+		return current, err
+	}
+	if reused {
+		if err := revokeSessions(r.db, "id = ?", current.FamilyID); err != nil {
+			return current, err
+		}
+		return current, ErrTokenReused
+	}
+
+	// Keep the session alive as long as its newest token
+	err = r.db.Model(&models.Session{}).Where("id = ?", current.FamilyID).
+		Updates(map[string]interface{}{"expires_at": next.ExpiresAt, "last_active_at": time.Now()}).Error
+	return current, err
+}
+
+// DeleteExpired removes tokens past their expiry. Rotated tokens are kept
+// until then so replays can still be detected.
+func (r *refreshTokenRepository) DeleteExpired() (int64, error) {
+	result := r.db.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
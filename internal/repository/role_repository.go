@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"github.com/dr15/internship-hub-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	FindAll() ([]models.Role, error)
+	FindByID(id string) (models.Role, error)
+	FindByName(name models.UserRole) (models.Role, error)
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	Delete(id string) error
+	CountUsers(name models.UserRole) (int64, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) FindAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Order("system DESC, name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) FindByID(id string) (models.Role, error) {
+	var role models.Role
+	err := r.db.First(&role, "id = ?", id).Error
+	return role, err
+}
+
+func (r *roleRepository) FindByName(name models.UserRole) (models.Role, error) {
+	var role models.Role
+	err := r.db.Where("name = ?", name).First(&role).Error
+	return role, err
+}
+
+func (r *roleRepository) Create(role *models.Role) error {
+	return r.db.Create(role).Error
+}
+
+func (r *roleRepository) Update(role *models.Role) error {
+	return r.db.Save(role).Error
+}
+
+// Delete removes the role for good so its name can be used again
+func (r *roleRepository) Delete(id string) error {
+	return r.db.Unscoped().Delete(&models.Role{}, "id = ?", id).Error
+}
+
+func (r *roleRepository) CountUsers(name models.UserRole) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
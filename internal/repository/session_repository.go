@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sessionActivityInterval limits how often a session's last activity is written
+const sessionActivityInterval = time.Minute
+
+type SessionRepository interface {
+	Create(session *models.Session, token *models.RefreshToken) error
+	FindByID(id uuid.UUID) (models.Session, error)
+	FindActiveByUser(userID uuid.UUID) ([]models.Session, error)
+	CountActiveByUsers(userIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	Touch(session *models.Session, ip, userAgent string) error
+	Revoke(id uuid.UUID) error
+	RevokeAllForUser(userID uuid.UUID, except *uuid.UUID) error
+	DeleteExpired() (int64, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create starts the session together with the first refresh token of its family
+func (r *sessionRepository) Create(session *models.Session, token *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		token.UserID = session.UserID
+		token.FamilyID = session.ID
+		return tx.Create(token).Error
+	})
+}
+
+func (r *sessionRepository) FindByID(id uuid.UUID) (models.Session, error) {
+	var session models.Session
+	err := r.db.First(&session, "id = ?", id).Error
+	return session, err
+}
+
+func (r *sessionRepository) FindActiveByUser(userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := activeSessions(r.db).Where("user_id = ?", userID).
+		Order("last_active_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+// CountActiveByUsers returns the number of active sessions per user
+func (r *sessionRepository) CountActiveByUsers(userIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		UserID uuid.UUID
+		Count  int64
+	}
+	err := activeSessions(r.db).Select("user_id, COUNT(*) AS count").
+		Where("user_id IN ?", userIDs).Group("user_id").Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	return counts, err
+}
+
+// Touch records activity on the session, at most once per minute
+func (r *sessionRepository) Touch(session *models.Session, ip, userAgent string) error {
+	if time.Since(session.LastActiveAt) < sessionActivityInterval {
+		return nil
+	}
+	updates := map[string]interface{}{"last_active_at": time.Now(), "ip_address": ip}
+	if userAgent != "" {
+		updates["user_agent"] = userAgent
+	}
+	return r.db.Model(session).Updates(updates).Error
+}
+
+// Revoke ends the session and its refresh tokens
+func (r *sessionRepository) Revoke(id uuid.UUID) error {
+	return revokeSessions(r.db, "id = ?", id)
+}
+
+// RevokeAllForUser ends every session of the user, optionally keeping one
+func (r *sessionRepository) RevokeAllForUser(userID uuid.UUID, except *uuid.UUID) error {
+	if except != nil {
+		return revokeSessions(r.db, "user_id = ? AND id <> ?", userID, *except)
+	}
+	return revokeSessions(r.db, "user_id = ?", userID)
+}
+
+// DeleteExpired removes sessions whose refresh tokens have all expired
+func (r *sessionRepository) DeleteExpired() (int64, error) {
+	result := r.db.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
+
+func activeSessions(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Session{}).Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+}
+
+// revokeSessions marks the matching sessions and the refresh tokens of their
+// families as revoked
+func revokeSessions(db *gorm.DB, query string, args ...interface{}) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Model(&models.Session{}).Where("revoked_at IS NULL").
+			Where(query, args...).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Session{}).Where("id IN ?", ids).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id IN ? AND revoked_at IS NULL", ids).
+			Update("revoked_at", now).Error
+	})
+}
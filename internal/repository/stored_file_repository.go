@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"github.com/dr15/internship-hub-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StoredFileRepository interface {
+	Register(file *models.StoredFile) error
+}
+
+type storedFileRepository struct {
+	db *gorm.DB
+}
+
+func NewStoredFileRepository(db *gorm.DB) StoredFileRepository {
+	return &storedFileRepository{db: db}
+}
+
+// Register records the file unless the same content is already known in its directory
+func (r *storedFileRepository) Register(file *models.StoredFile) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(file).Error
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -11,10 +12,14 @@ type UserRepository interface {
 	Create(user *models.User) error
 	FindByEmail(email string) (models.User, error)
 	FindByID(id string) (models.User, error)
+	FindAuthState(id uuid.UUID) (models.User, error)
 	FindByResetToken(token string) (models.User, error)
+	FindByVerificationToken(token string) (models.User, error)
+	FindByOIDCSubject(issuer, subject string) (models.User, error)
 	Update(user *models.User) error
 	FindAll(role string, search string, page, limit int) ([]models.User, int64, error)
 	Delete(id string) error
+	FindByUnit(unitID uuid.UUID, roles []models.UserRole) ([]models.User, error)
 }
 
 type userRepository struct {
@@ -41,12 +46,32 @@ func (r *userRepository) FindByID(id string) (models.User, error) {
 	return user, err
 }
 
+// FindAuthState loads only the fields needed to check an access token and
+// authorize its request
+func (r *userRepository) FindAuthState(id uuid.UUID) (models.User, error) {
+	var user models.User
+	err := r.db.Select("id", "role", "unit_kerja_id", "tokens_valid_after", "deactivated_at").First(&user, "id = ?", id).Error
+	return user, err
+}
+
 func (r *userRepository) FindByResetToken(token string) (models.User, error) {
 	var user models.User
 	err := r.db.Where("reset_token = ?", token).First(&user).Error
 	return user, err
 }
 
+func (r *userRepository) FindByVerificationToken(token string) (models.User, error) {
+	var user models.User
+	err := r.db.Where("verification_token = ?", token).First(&user).Error
+	return user, err
+}
+
+func (r *userRepository) FindByOIDCSubject(issuer, subject string) (models.User, error) {
+	var user models.User
+	err := r.db.Where("oidc_issuer = ? AND oidc_subject = ?", issuer, subject).Preload("UnitKerja").First(&user).Error
+	return user, err
+}
+
 func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
@@ -76,3 +101,12 @@ func (r *userRepository) FindAll(role string, search string, page, limit int) ([
 func (r *userRepository) Delete(id string) error {
 	return r.db.Delete(&models.User{}, "id = ?", id).Error
 }
+
+func (r *userRepository) FindByUnit(unitID uuid.UUID, roles []models.UserRole) ([]models.User, error) {
+	var users []models.User
+	if len(roles) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("unit_kerja_id = ? AND role IN ?", unitID, roles).Find(&users).Error
+	return users, err
+}
@@ -1,17 +1,31 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"github.com/dr15/internship-hub-api/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVacancyChanged  = errors.New("vacancy status changed while it was being edited")
+	ErrQuotaBelowTaken = errors.New("quota is lower than the seats already taken")
 )
 
 type VacancyRepository interface {
 	FindAll(unitID string, search string, page, limit int) ([]models.Vacancy, int64, error)
 	FindByID(id string) (models.Vacancy, error)
 	Create(vacancy *models.Vacancy) error
+	Update(vacancy *models.Vacancy, from models.VacancyStatus, revision *models.VacancyRevision) error
 	UpdateStatus(id string, status models.VacancyStatus, rejectionNote string) error
-	FindAllAdmin(role models.UserRole, unitID *uuid.UUID, status string, search string, page, limit int) ([]models.Vacancy, int64, error)
+	FindRevisions(vacancyID string) ([]models.VacancyRevision, error)
+	ReplaceQuestions(vacancyID uuid.UUID, questions []models.VacancyQuestion, revision *models.VacancyRevision) error
+	CloseExpired() (int64, error)
+	FindAllAdmin(unitScoped bool, viewerID uuid.UUID, unitID *uuid.UUID, status string, search string, page, limit int) ([]models.Vacancy, int64, error)
+	FindApprovalQueue(page, limit int) ([]models.Vacancy, int64, error)
 }
 
 type vacancyRepository struct {
@@ -35,29 +49,225 @@ func (r *vacancyRepository) FindAll(unitID string, search string, page, limit in
 	}
 
 	query.Count(&total)
-	err := query.Offset((page - 1) * limit).Limit(limit).Find(&vacancies).Error
+	if err := query.Offset((page - 1) * limit).Limit(limit).Find(&vacancies).Error; err != nil {
+		return nil, 0, err
+	}
+	err := r.attachCounts(vacancies)
 	return vacancies, total, err
 }
 
 func (r *vacancyRepository) FindByID(id string) (models.Vacancy, error) {
 	var vacancy models.Vacancy
-	err := r.db.Preload("UnitKerja").First(&vacancy, "id = ?", id).Error
-	return vacancy, err
+	if err := r.db.Preload("UnitKerja").Preload("Questions", orderQuestions).First(&vacancy, "id = ?", id).Error; err != nil {
+		return vacancy, err
+	}
+	vacancies := []models.Vacancy{vacancy}
+	err := r.attachCounts(vacancies)
+	return vacancies[0], err
+}
+
+// attachCounts fills the applicant and seat counters of the given vacancies
+func (r *vacancyRepository) attachCounts(vacancies []models.Vacancy) error {
+	if len(vacancies) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(vacancies))
+	for i, v := range vacancies {
+		ids[i] = v.ID
+	}
+
+	var rows []struct {
+		VacancyID      uuid.UUID
+		ApplicantCount int64
+		AcceptedCount  int64
+	}
+	err := r.db.Model(&models.Application{}).
+		Select("vacancy_id, COUNT(*) AS applicant_count, COUNT(*) FILTER (WHERE status IN ?) AS accepted_count", SeatHoldingStatuses).
+		Where("vacancy_id IN ? AND status <> ?", ids, models.ApplicationStatusWithdrawn).
+		Group("vacancy_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	byVacancy := make(map[uuid.UUID]int, len(rows))
+	for i, row := range rows {
+		byVacancy[row.VacancyID] = i
+	}
+	for i := range vacancies {
+		v := &vacancies[i]
+		if idx, ok := byVacancy[v.ID]; ok {
+			v.ApplicantCount = rows[idx].ApplicantCount
+			v.AcceptedCount = rows[idx].AcceptedCount
+		}
+		v.RemainingSeats = v.Quota - int(v.AcceptedCount)
+		if v.RemainingSeats < 0 {
+			v.RemainingSeats = 0
+		}
+	}
+	return nil
 }
 
 func (r *vacancyRepository) Create(vacancy *models.Vacancy) error {
 	return r.db.Create(vacancy).Error
 }
 
+// vacancyEditColumns are the columns written by Update
+var vacancyEditColumns = []string{
+	"title", "unit_kerja_id", "description", "requirements", "required_documents", "duration",
+	"duration_months", "location", "quota", "deadline", "status", "rejection_note", "submitted_by",
+	"submitted_at", "updated_at",
+}
+
+// Update saves an edit of the vacancy and, when given, the revision
+// describing it in a single transaction. The vacancy is locked and must still
+// have the status from, so a close or approval that happened since it was
+// read is not overwritten. The quota may not drop below the seats taken.
+func (r *vacancyRepository) Update(vacancy *models.Vacancy, from models.VacancyStatus, revision *models.VacancyRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Vacancy
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", vacancy.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != from {
+			return ErrVacancyChanged
+		}
+		taken, err := countSeatsTaken(tx, vacancy.ID)
+		if err != nil {
+			return err
+		}
+		if int64(vacancy.Quota) < taken {
+			return ErrQuotaBelowTaken
+		}
+
+		if err := tx.Model(&current).Select(vacancyEditColumns).Updates(vacancy).Error; err != nil {
+			return err
+		}
+		if revision == nil || len(revision.Changes) == 0 {
+			return nil
+		}
+		revision.VacancyID = vacancy.ID
+		return tx.Create(revision).Error
+	})
+}
+
 func (r *vacancyRepository) UpdateStatus(id string, status models.VacancyStatus, rejectionNote string) error {
 	updates := map[string]interface{}{"status": status}
 	if status == models.VacancyStatusRejected {
 		updates["rejection_note"] = rejectionNote
 	}
+	if status == models.VacancyStatusClosed {
+		updates["closed_at"] = time.Now()
+	}
 	return r.db.Model(&models.Vacancy{}).Where("id = ?", id).Updates(updates).Error
 }
 
-func (r *vacancyRepository) FindAllAdmin(role models.UserRole, unitID *uuid.UUID, status string, search string, page, limit int) ([]models.Vacancy, int64, error) {
+func (r *vacancyRepository) FindApprovalQueue(page, limit int) ([]models.Vacancy, int64, error) {
+	var vacancies []models.Vacancy
+	var total int64
+
+	query := r.db.Model(&models.Vacancy{}).
+		Preload("UnitKerja").
+		Preload("Submitter").
+		Where("status = ?", models.VacancyStatusPending)
+
+	query.Count(&total)
+	err := query.Order("submitted_at asc NULLS FIRST, created_at asc").Offset((page - 1) * limit).Limit(limit).Find(&vacancies).Error
+	return vacancies, total, err
+}
+
+func (r *vacancyRepository) FindRevisions(vacancyID string) ([]models.VacancyRevision, error) {
+	var revisions []models.VacancyRevision
+	err := r.db.Preload("Changes").Preload("Editor").
+		Where("vacancy_id = ?", vacancyID).
+		Order("created_at desc").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// ReplaceQuestions makes the given list the vacancy's questions. Questions with
+// a known ID are updated, new ones created and the ones left out removed.
+// When the revision records a change, an approved or rejected vacancy goes
+// back to pending approval.
+func (r *vacancyRepository) ReplaceQuestions(vacancyID uuid.UUID, questions []models.VacancyQuestion, revision *models.VacancyRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing []uuid.UUID
+		if err := tx.Model(&models.VacancyQuestion{}).Where("vacancy_id = ?", vacancyID).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		known := make(map[uuid.UUID]bool, len(existing))
+		for _, id := range existing {
+			known[id] = true
+		}
+
+		kept := make([]uuid.UUID, 0, len(questions))
+		for i := range questions {
+			q := &questions[i]
+			q.VacancyID = vacancyID
+			if q.ID != uuid.Nil && !known[q.ID] {
+				// IDs of other vacancies' questions are not taken over
+				q.ID = uuid.Nil
+			}
+			if q.ID == uuid.Nil {
+				if err := tx.Create(q).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Model(q).Updates(map[string]interface{}{
+				"position": q.Position,
+				"label":    q.Label,
+				"type":     q.Type,
+				"required": q.Required,
+				"options":  q.Options,
+				"multiple": q.Multiple,
+				"min":      q.Min,
+				"max":      q.Max,
+			}).Error; err != nil {
+				return err
+			}
+			kept = append(kept, q.ID)
+		}
+
+		removed := tx.Where("vacancy_id = ?", vacancyID)
+		if len(kept) > 0 {
+			removed = removed.Where("id NOT IN ?", kept)
+		}
+		if err := removed.Delete(&models.VacancyQuestion{}).Error; err != nil {
+			return err
+		}
+
+		if revision == nil || len(revision.Changes) == 0 {
+			return nil
+		}
+
+		// Changed questions need central re-approval, like any other edit of
+		// an approved or rejected vacancy
+		var vacancy models.Vacancy
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vacancy, "id = ?", vacancyID).Error; err != nil {
+			return err
+		}
+		if vacancy.Status == models.VacancyStatusApproved || vacancy.Status == models.VacancyStatusRejected {
+			if err := tx.Model(&vacancy).Updates(map[string]interface{}{
+				"status":         models.VacancyStatusPending,
+				"rejection_note": "",
+				"submitted_by":   revision.EditedBy,
+				"submitted_at":   time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		revision.VacancyID = vacancyID
+		revision.PreviousStatus = vacancy.Status
+		return tx.Create(revision).Error
+	})
+}
+
+func orderQuestions(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc")
+}
+
+func (r *vacancyRepository) FindAllAdmin(unitScoped bool, viewerID uuid.UUID, unitID *uuid.UUID, status string, search string, page, limit int) ([]models.Vacancy, int64, error) {
 	var vacancies []models.Vacancy
 	var total int64
 
@@ -65,14 +275,35 @@ func (r *vacancyRepository) FindAllAdmin(role models.UserRole, unitID *uuid.UUID
 	if unitID != nil {
 		query = query.Where("unit_kerja_id = ?", unitID)
 	}
+	// Drafts belong to the unit that owns them; admins of all units only see their own
+	if !unitScoped {
+		query = query.Where("status <> ? OR created_by = ?", models.VacancyStatusDraft, viewerID)
+	}
 	if status != "" {
 		query = query.Where("status = ?", status)
+	} else {
+		// Archived vacancies are only listed when explicitly requested
+		query = query.Where("status <> ?", models.VacancyStatusArchived)
 	}
 	if search != "" {
 		query = query.Where("title ILIKE ? OR description ILIKE ?", "%"+search+"%", "%"+search+"%")
 	}
 
 	query.Count(&total)
-	err := query.Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&vacancies).Error
+	if err := query.Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&vacancies).Error; err != nil {
+		return nil, 0, err
+	}
+	err := r.attachCounts(vacancies)
 	return vacancies, total, err
 }
+
+// CloseExpired closes approved vacancies whose deadline day has passed
+func (r *vacancyRepository) CloseExpired() (int64, error) {
+	result := r.db.Model(&models.Vacancy{}).
+		Where("status = ? AND deadline::date < CURRENT_DATE", models.VacancyStatusApproved).
+		Updates(map[string]interface{}{
+			"status":    models.VacancyStatusClosed,
+			"closed_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+// leaderLockKey is the Postgres advisory lock held by the replica that runs jobs
+const leaderLockKey int64 = 73019001
+
+// JobFunc performs a job and returns the number of records it affected
+type JobFunc func() (int64, error)
+
+type job struct {
+	name     string
+	schedule Schedule
+	run      JobFunc
+}
+
+// Scheduler runs registered jobs on their cron schedule. When several API
+// replicas are running, only the one holding the advisory lock executes jobs.
+type Scheduler struct {
+	db       *gorm.DB
+	runs     repository.JobRunRepository
+	instance string
+	jobs     []job
+
+	mu     sync.Mutex
+	leader *sql.Conn
+}
+
+func New(db *gorm.DB, runs repository.JobRunRepository) *Scheduler {
+	instance, _ := os.Hostname()
+	return &Scheduler{db: db, runs: runs, instance: instance}
+}
+
+// Register adds a job. It returns an error if the cron expression is invalid.
+func (s *Scheduler) Register(name, cronExpr string, run JobFunc) error {
+	schedule, err := ParseSchedule(cronExpr)
+	if err != nil {
+		return err
+	}
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Start blocks until ctx is cancelled, checking the schedules once a minute
+func (s *Scheduler) Start(ctx context.Context) {
+	defer s.resign()
+
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next.Sub(now)):
+		}
+
+		if !s.ensureLeader(ctx) {
+			continue
+		}
+
+		for _, j := range s.jobs {
+			if j.schedule.Matches(next) {
+				s.execute(j)
+			}
+		}
+	}
+}
+
+// ensureLeader tries to take or keep the advisory lock. The lock lives on a
+// dedicated connection, so it is released automatically if that connection dies.
+func (s *Scheduler) ensureLeader(ctx context.Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.leader != nil {
+		if err := s.leader.PingContext(ctx); err == nil {
+			return true
+		}
+		log.Println("Scheduler: lost leader connection")
+		s.leader.Close()
+		s.leader = nil
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return false
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&acquired); err != nil || !acquired {
+		conn.Close()
+		return false
+	}
+
+	log.Printf("Scheduler: %s elected as leader", s.instance)
+	s.leader = conn
+	return true
+}
+
+func (s *Scheduler) resign() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.leader == nil {
+		return
+	}
+	s.leader.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", leaderLockKey)
+	s.leader.Close()
+	s.leader = nil
+}
+
+func (s *Scheduler) execute(j job) {
+	run := models.JobRun{
+		Name:      j.name,
+		Instance:  s.instance,
+		Status:    models.JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.runs.Create(&run); err != nil {
+		log.Printf("Scheduler: failed to record run of %s: %v", j.name, err)
+	}
+
+	affected, err := j.run()
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Affected = affected
+	run.Status = models.JobRunStatusSucceeded
+	if err != nil {
+		run.Status = models.JobRunStatusFailed
+		run.Error = err.Error()
+		log.Printf("Scheduler: job %s failed: %v", j.name, err)
+	}
+
+	if err := s.runs.Update(&run); err != nil {
+		log.Printf("Scheduler: failed to record result of %s: %v", j.name, err)
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dr15/internship-hub-api/config"
+	"github.com/dr15/internship-hub-api/internal/repository"
+)
+
+const (
+	// throttleFreeFailures is how many failures are allowed before delays start
+	throttleFreeFailures = 3
+	// throttleMaxDelay caps the wait between attempts before a lockout
+	throttleMaxDelay = 30 * time.Second
+)
+
+// ThrottlePolicy is how many failures a key may collect before it is locked
+type ThrottlePolicy struct {
+	MaxFailures int
+	Lockout     time.Duration
+}
+
+// AuthThrottleService slows down and locks out repeated failed sign-ins and
+// password reset requests per account and per IP address
+type AuthThrottleService struct {
+	Repo repository.AuthThrottleRepository
+}
+
+func NewAuthThrottleService(repo repository.AuthThrottleRepository) *AuthThrottleService {
+	return &AuthThrottleService{Repo: repo}
+}
+
+// AccountKey and IPKey name the counters of an action, e.g. "login"
+func AccountKey(action, email string) string {
+	return action + ":account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPKey(action, ip string) string {
+	return action + ":ip:" + ip
+}
+
+// AccountPolicy and IPPolicy read the configured limits. An IP address gets
+// more room since several people may share one.
+func AccountPolicy() ThrottlePolicy {
+	return ThrottlePolicy{MaxFailures: configInt(config.AppConfig.LoginMaxFailures, 5), Lockout: lockoutDuration()}
+}
+
+func IPPolicy() ThrottlePolicy {
+	return ThrottlePolicy{MaxFailures: configInt(config.AppConfig.LoginMaxFailuresPerIP, 20), Lockout: lockoutDuration()}
+}
+
+func lockoutDuration() time.Duration {
+	return time.Duration(configInt(config.AppConfig.LoginLockoutMinutes, 15)) * time.Minute
+}
+
+func configInt(value string, fallback int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
+
+// Wait returns how long the caller must wait before another attempt on any
+// of the keys, and whether that is because of a lockout rather than a delay
+func (s *AuthThrottleService) Wait(keys ...string) (time.Duration, bool, error) {
+	throttles, err := s.Repo.FindByKeys(keys)
+	if err != nil {
+		return 0, false, err
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	locked := false
+	for _, t := range throttles {
+		var d time.Duration
+		if t.LockedUntil != nil && t.LockedUntil.After(now) {
+			d = t.LockedUntil.Sub(now)
+			locked = true
+		} else {
+			d = t.LastFailureAt.Add(failureDelay(t.Failures)).Sub(now)
+		}
+		if d > wait {
+			wait = d
+		}
+	}
+	return wait, locked, nil
+}
+
+// failureDelay doubles the wait with every failure past the free ones
+func failureDelay(failures int) time.Duration {
+	if failures < throttleFreeFailures {
+		return 0
+	}
+	delay := time.Second << uint(failures-throttleFreeFailures)
+	if delay > throttleMaxDelay || delay <= 0 {
+		delay = throttleMaxDelay
+	}
+	return delay
+}
+
+// Fail records a failed attempt on the key and locks it once the policy's
+// limit is reached. It returns the lockout end when this failure caused it.
+func (s *AuthThrottleService) Fail(key string, policy ThrottlePolicy) (*time.Time, error) {
+	throttle, err := s.Repo.RecordFailure(key, policy.Lockout)
+	if err != nil {
+		return nil, err
+	}
+	if throttle.Failures < policy.MaxFailures {
+		return nil, nil
+	}
+
+	until := time.Now().Add(policy.Lockout)
+	locked, err := s.Repo.Lock(key, until)
+	if err != nil || !locked {
+		return nil, err
+	}
+	log.Printf("Locked %s until %s after %d failures", key, until.Format(time.RFC3339), throttle.Failures)
+	return &until, nil
+}
+
+// Succeed clears the counters of the keys after a successful attempt
+func (s *AuthThrottleService) Succeed(keys ...string) error {
+	return s.Repo.Reset(keys...)
+}
+
+// PurgeStale removes counters that no longer hold back anyone
+func (s *AuthThrottleService) PurgeStale() (int64, error) {
+	return s.Repo.DeleteStale(time.Now().Add(-lockoutDuration()))
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// roleCacheTTL bounds how long a role edited on another instance keeps its
+// old permissions here
+const roleCacheTTL = 30 * time.Second
+
+// Principal is the signed-in user a permission is checked for
+type Principal struct {
+	Role        models.UserRole
+	UnitKerjaID *uuid.UUID
+}
+
+// AuthorizationService answers whether a user's role grants a permission.
+// Roles are cached briefly since every request asks.
+type AuthorizationService struct {
+	Repo repository.RoleRepository
+
+	mu       sync.Mutex
+	roles    map[models.UserRole]models.Role
+	loadedAt time.Time
+}
+
+func NewAuthorizationService(repo repository.RoleRepository) *AuthorizationService {
+	return &AuthorizationService{Repo: repo}
+}
+
+// Authorize reports whether the principal holds the permission for the given
+// unit, or in general when unitID is nil. It also returns the unit the
+// principal is limited to, nil meaning all units, which listings use as
+// their filter. Unit scoped roles never get permissions that only make sense
+// across all units, and need a unit of their own to act at all.
+func (s *AuthorizationService) Authorize(p Principal, permission models.Permission, unitID *uuid.UUID) (*uuid.UUID, bool) {
+	role, ok := s.Role(p.Role)
+	if !ok || !role.Has(permission) {
+		return nil, false
+	}
+	if !role.UnitScoped {
+		return nil, true
+	}
+
+	info, known := models.FindPermission(permission)
+	if !known || !info.UnitScopable || p.UnitKerjaID == nil {
+		return nil, false
+	}
+	if unitID != nil && *unitID != *p.UnitKerjaID {
+		return nil, false
+	}
+	return p.UnitKerjaID, true
+}
+
+// Role returns the definition of a role by name
+func (s *AuthorizationService) Role(name models.UserRole) (models.Role, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.roles == nil || time.Since(s.loadedAt) > roleCacheTTL {
+		if err := s.load(); err != nil {
+			log.Printf("Failed to load roles: %v", err)
+			if s.roles == nil {
+				return models.Role{}, false
+			}
+		}
+	}
+	role, ok := s.roles[name]
+	return role, ok
+}
+
+// RolesWith returns the names of the roles that grant the permission
+func (s *AuthorizationService) RolesWith(permission models.Permission) ([]models.UserRole, error) {
+	roles, err := s.Repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	var names []models.UserRole
+	for _, role := range roles {
+		if role.Has(permission) {
+			names = append(names, role.Name)
+		}
+	}
+	return names, nil
+}
+
+// RequiresTwoFactor reports whether users of the role must use a second factor.
+// A role that cannot be resolved, e.g. because the roles failed to load, is
+// treated as requiring it.
+func (s *AuthorizationService) RequiresTwoFactor(name models.UserRole) bool {
+	role, ok := s.Role(name)
+	if !ok {
+		return true
+	}
+	return role.RequireTwoFactor
+}
+
+// Invalidate drops the cached roles after an edit
+func (s *AuthorizationService) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roles = nil
+}
+
+func (s *AuthorizationService) load() error {
+	roles, err := s.Repo.FindAll()
+	if err != nil {
+		return err
+	}
+	s.roles = make(map[models.UserRole]models.Role, len(roles))
+	for _, role := range roles {
+		s.roles[role.Name] = role
+	}
+	s.loadedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/dr15/internship-hub-api/internal/utils"
+)
+
+// NotificationService sends emails about application events to the people involved
+type NotificationService struct {
+	UserRepo repository.UserRepository
+	Authz    *AuthorizationService
+}
+
+func NewNotificationService(userRepo repository.UserRepository, authz *AuthorizationService) *NotificationService {
+	return &NotificationService{UserRepo: userRepo, Authz: authz}
+}
+
+// unitAdminEmails returns the addresses of the members of a unit who review
+// its applications
+func (s *NotificationService) unitAdminEmails(vacancy models.Vacancy) []string {
+	roles, err := s.Authz.RolesWith(models.PermissionApplicationReview)
+	if err != nil {
+		log.Printf("Failed to load roles for unit %s: %v", vacancy.UnitKerjaID, err)
+		return nil
+	}
+	admins, err := s.UserRepo.FindByUnit(vacancy.UnitKerjaID, roles)
+	if err != nil {
+		log.Printf("Failed to load admins of unit %s: %v", vacancy.UnitKerjaID, err)
+		return nil
+	}
+
+	emails := make([]string, 0, len(admins))
+	for _, admin := range admins {
+		emails = append(emails, admin.Email)
+	}
+	return emails
+}
+
+// OfferReleased tells the unit that an offer was declined or expired and its seat is free again
+func (s *NotificationService) OfferReleased(app models.Application, reason string) {
+	emails := s.unitAdminEmails(app.Vacancy)
+	if len(emails) == 0 {
+		return
+	}
+
+	applicant := app.User
+	if applicant.ID != app.UserID {
+		applicant, _ = s.UserRepo.FindByID(app.UserID.String())
+	}
+
+	if err := utils.SendOfferReleasedEmail(emails, applicant.Name, app.Vacancy.Title, reason); err != nil {
+		log.Printf("Failed to notify unit about released offer %s: %v", app.ID, err)
+	}
+}
+
+// WaitlistPromoted tells an applicant that a seat opened up and they now have an offer
+func (s *NotificationService) WaitlistPromoted(app models.Application) {
+	if app.OfferExpiresAt == nil {
+		return
+	}
+	if err := utils.SendWaitlistPromotedEmail(app.User.Email, app.User.Name, app.Vacancy.Title, *app.OfferExpiresAt); err != nil {
+		log.Printf("Failed to notify applicant about waitlist promotion %s: %v", app.ID, err)
+	}
+}
+
+// AccountLocked warns a user that their account was locked after repeated failed logins
+func (s *NotificationService) AccountLocked(user models.User, until time.Time) {
+	if err := utils.SendAccountLockedEmail(user.Email, user.Name, until); err != nil {
+		log.Printf("Failed to notify user %s about account lockout: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/dr15/internship-hub-api/config"
+	"github.com/dr15/internship-hub-api/internal/models"
+	"golang.org/x/oauth2"
+)
+
+var ErrOIDCDisabled = errors.New("single sign-on is not configured")
+
+// OIDCIdentity is what the identity provider tells us about a signed-in user
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Claims        map[string]interface{}
+}
+
+// OIDCService runs the authorization code flow with PKCE against the
+// configured identity provider. The provider is discovered on first use, so
+// the API can start while it is unreachable.
+type OIDCService struct {
+	cfg *config.Config
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func NewOIDCService(cfg *config.Config) *OIDCService {
+	return &OIDCService{cfg: cfg}
+}
+
+// Enabled reports whether an identity provider is configured
+func (s *OIDCService) Enabled() bool {
+	return s.cfg.OIDCIssuerURL != ""
+}
+
+// StaffPasswordLogin reports whether unit and central admins may still log
+// in with a password
+func (s *OIDCService) StaffPasswordLogin() bool {
+	return !s.Enabled() || s.cfg.OIDCAllowStaffPassword == "true"
+}
+
+// FrontendURL is where the callback sends the browser when it is done
+func (s *OIDCService) FrontendURL() string {
+	return s.cfg.OIDCFrontendURL
+}
+
+func (s *OIDCService) discover(ctx context.Context) error {
+	if !s.Enabled() {
+		return ErrOIDCDisabled
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider != nil {
+		return nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, s.cfg.OIDCIssuerURL)
+	if err != nil {
+		return fmt.Errorf("discover identity provider: %w", err)
+	}
+	s.provider = provider
+	s.oauth = oauth2.Config{
+		ClientID:     s.cfg.OIDCClientID,
+		ClientSecret: s.cfg.OIDCClientSecret,
+		RedirectURL:  s.cfg.OIDCRedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       strings.Fields(s.cfg.OIDCScopes),
+	}
+	s.verifier = provider.Verifier(&oidc.Config{ClientID: s.cfg.OIDCClientID})
+	return nil
+}
+
+// AuthURL is the identity provider page that starts a sign-in
+func (s *OIDCService) AuthURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	if err := s.discover(ctx); err != nil {
+		return "", err
+	}
+	return s.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+// Exchange trades the authorization code for tokens and verifies the ID
+// token. Claims missing from the ID token are filled in from the userinfo
+// endpoint when the provider has one.
+func (s *OIDCService) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*OIDCIdentity, error) {
+	if err := s.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	token, err := s.oauth.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange authorization code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+	idToken, err := s.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("read id token claims: %w", err)
+	}
+	if s.provider.UserInfoEndpoint() != "" {
+		info, err := s.provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+		if err != nil {
+			return nil, fmt.Errorf("fetch userinfo: %w", err)
+		}
+		// The userinfo subject must be the one the ID token was issued for
+		if info.Subject != idToken.Subject {
+			return nil, errors.New("userinfo subject does not match id token")
+		}
+		extra := map[string]interface{}{}
+		if err := info.Claims(&extra); err != nil {
+			return nil, fmt.Errorf("read userinfo claims: %w", err)
+		}
+		for k, v := range extra {
+			if _, ok := claims[k]; !ok {
+				claims[k] = v
+			}
+		}
+	}
+
+	identity := &OIDCIdentity{
+		Issuer:  idToken.Issuer,
+		Subject: idToken.Subject,
+		Claims:  claims,
+	}
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	return identity, nil
+}
+
+// MatchRoleMapping returns the first rule, in the given order, whose claim
+// holds its value. A claim may name a nested field with dots, such as
+// "realm_access.roles", and may be a single value or a list.
+func MatchRoleMapping(claims map[string]interface{}, mappings []models.OIDCRoleMapping) *models.OIDCRoleMapping {
+	for i, mapping := range mappings {
+		for _, value := range claimValues(claims, mapping.Claim) {
+			if value == mapping.Value {
+				return &mappings[i]
+			}
+		}
+	}
+	return nil
+}
+
+func claimValues(claims map[string]interface{}, path string) []string {
+	var current interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[part]
+	}
+
+	switch v := current.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
@@ -1,25 +1,31 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/dr15/internship-hub-api/internal/models"
+	"github.com/dr15/internship-hub-api/internal/storage"
 	"github.com/jung-kurt/gofpdf/v2"
 )
 
 type PDFService struct {
-	UploadDir string
+	Storage storage.Storage
 }
 
-func NewPDFService(uploadDir string) *PDFService {
-	// Ensure directory exists
-	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		os.MkdirAll(uploadDir, 0755)
+func NewPDFService(store storage.Storage) *PDFService {
+	return &PDFService{Storage: store}
+}
+
+// save renders the document and writes it to the file storage
+func (s *PDFService) save(pdf *gofpdf.Fpdf, filename string) error {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return err
 	}
-	return &PDFService{UploadDir: uploadDir}
+	return s.Storage.Put(context.Background(), filename, &buf, int64(buf.Len()), "application/pdf")
 }
 
 func (s *PDFService) GenerateCompletionLetter(result *models.InternshipResult) (string, error) {
@@ -71,8 +77,7 @@ func (s *PDFService) GenerateCompletionLetter(result *models.InternshipResult) (
 	pdf.CellFormat(0, 10, "( Administrator )", "", 1, "R", false, 0, "")
 
 	filename := fmt.Sprintf("completion_%s.pdf", result.ApplicationID.String())
-	path := filepath.Join(s.UploadDir, filename)
-	err := pdf.OutputFileAndClose(path)
+	err := s.save(pdf, filename)
 	return filename, err
 }
 
@@ -113,8 +118,7 @@ func (s *PDFService) GenerateCertificate(result *models.InternshipResult) (strin
 	pdf.CellFormat(0, 10, time.Now().Format("02 January 2006"), "", 1, "C", false, 0, "")
 
 	filename := fmt.Sprintf("certificate_%s.pdf", result.ApplicationID.String())
-	path := filepath.Join(s.UploadDir, filename)
-	err := pdf.OutputFileAndClose(path)
+	err := s.save(pdf, filename)
 	return filename, err
 }
 
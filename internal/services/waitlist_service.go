@@ -0,0 +1,70 @@
+package services
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/dr15/internship-hub-api/config"
+	"github.com/dr15/internship-hub-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// WaitlistService hands seats that become free to waitlisted applicants
+type WaitlistService struct {
+	AppRepo  repository.ApplicationRepository
+	Notifier *NotificationService
+}
+
+func NewWaitlistService(appRepo repository.ApplicationRepository, notifier *NotificationService) *WaitlistService {
+	return &WaitlistService{AppRepo: appRepo, Notifier: notifier}
+}
+
+// DefaultOfferExpiry is when an offer made now lapses if the unit gives no deadline
+func DefaultOfferExpiry() time.Time {
+	days, err := strconv.Atoi(config.AppConfig.OfferResponseDays)
+	if err != nil || days < 1 {
+		days = 7
+	}
+	return time.Now().AddDate(0, 0, days)
+}
+
+// Promote offers the free seats of a vacancy to its waitlist and notifies the promoted applicants
+func (s *WaitlistService) Promote(vacancyID uuid.UUID) (int64, error) {
+	promoted, err := s.AppRepo.PromoteWaitlisted(vacancyID, DefaultOfferExpiry())
+	if err != nil {
+		return 0, err
+	}
+	for _, app := range promoted {
+		s.Notifier.WaitlistPromoted(app)
+	}
+	return int64(len(promoted)), nil
+}
+
+// PromoteAll runs Promote for every vacancy with a waitlist. It catches seats
+// freed without a promotion, e.g. by a quota increase or a failed attempt.
+func (s *WaitlistService) PromoteAll() (int64, error) {
+	ids, err := s.AppRepo.FindWaitlistedVacancyIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, id := range ids {
+		promoted, err := s.Promote(id)
+		if err != nil {
+			return total, err
+		}
+		total += promoted
+	}
+	return total, nil
+}
+
+// PromoteAsync runs Promote in the background after a seat was released
+func (s *WaitlistService) PromoteAsync(vacancyID uuid.UUID) {
+	go func() {
+		if _, err := s.Promote(vacancyID); err != nil {
+			log.Printf("Failed to promote waitlist of vacancy %s: %v", vacancyID, err)
+		}
+	}()
+}
@@ -0,0 +1,65 @@
+// Package storage keeps uploaded and generated files behind one interface so
+// every API replica sees the same files, whichever backend holds them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/dr15/internship-hub-api/config"
+)
+
+// ErrNotFound is returned when no file is stored under a key
+var ErrNotFound = errors.New("file not found")
+
+// Storage stores files under slash separated keys such as "cv/<name>.pdf".
+// Put takes the size of the content, or -1 when it is not known.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every key starting with prefix
+	List(ctx context.Context, prefix string, fn func(key string) error) error
+}
+
+// FromConfig opens the backend named by driver ("local" or "s3") using the
+// settings in the app config
+func FromConfig(driver string, cfg *config.Config) (Storage, error) {
+	switch driver {
+	case "", "local":
+		return NewLocal(cfg.StorageLocalDir), nil
+	case "s3":
+		s3, err := NewS3(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle == "true",
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s3, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// Key joins path elements into a storage key
+func Key(elem ...string) string {
+	return strings.TrimPrefix(path.Join(elem...), "/")
+}
+
+// validKey rejects keys that are empty or could escape the storage root
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
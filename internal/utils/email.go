@@ -1,28 +1,94 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"html"
 	"log"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
 
 	"github.com/dr15/internship-hub-api/config"
 )
 
-func SendResetPasswordEmail(toEmail, token string) error {
+// Attachment is a file sent along with an email
+type Attachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// SendEmail sends an HTML email. In development, when SMTP is not configured,
+// the message is printed to stdout instead.
+func SendEmail(to []string, subject, body string, attachments ...Attachment) error {
 	conf := config.AppConfig
 
-	// In development, if SMTP is not configured, just log the token
 	if conf.SMTPHost == "localhost" || conf.SMTPUser == "" {
 		fmt.Printf("\n--- DEVELOPMENT EMAIL MOCK ---\n")
-		fmt.Printf("To: %s\n", toEmail)
-		fmt.Printf("Subject: Reset Password\n")
-		fmt.Printf("Link: http://localhost:5173/reset-password?token=%s\n", token)
+		fmt.Printf("To: %s\n", strings.Join(to, ", "))
+		fmt.Printf("Subject: %s\n", subject)
+		fmt.Printf("%s\n", body)
+		for _, a := range attachments {
+			fmt.Printf("Attachment: %s (%d bytes)\n", a.FileName, len(a.Data))
+		}
 		fmt.Printf("------------------------------\n\n")
 		return nil
 	}
 
-	subject := "Subject: Reset Password - Internship Hub\n"
-	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+	header := "Subject: " + subject + "\n"
+	var msg []byte
+	if len(attachments) == 0 {
+		mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+		msg = []byte(header + mime + body)
+	} else {
+		msg = buildMultipartMessage(header, body, attachments)
+	}
+
+	auth := smtp.PlainAuth("", conf.SMTPUser, conf.SMTPPass, conf.SMTPHost)
+
+	err := smtp.SendMail(conf.SMTPHost+":"+conf.SMTPPort, auth, conf.SMTPSender, to, msg)
+	if err != nil {
+		log.Printf("Failed to send email: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func buildMultipartMessage(header, body string, attachments []Attachment) []byte {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	buf.WriteString(header)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: multipart/mixed; boundary=" + writer.Boundary() + "\r\n\r\n")
+
+	part, _ := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=\"UTF-8\""}})
+	part.Write([]byte(body))
+
+	for _, a := range attachments {
+		part, _ := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.FileName)},
+		})
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+
+	writer.Close()
+	return buf.Bytes()
+}
+
+func SendResetPasswordEmail(toEmail, token string) error {
 	body := fmt.Sprintf(`
 		<h3>Reset Password</h3>
 		<p>Anda menerima email ini karena Anda (atau seseorang) meminta reset password untuk akun Anda.</p>
@@ -31,14 +97,77 @@ func SendResetPasswordEmail(toEmail, token string) error {
 		<p>Jika Anda tidak meminta ini, abaikan email ini.</p>
 	`, token)
 
-	msg := []byte(subject + mime + body)
-	auth := smtp.PlainAuth("", conf.SMTPUser, conf.SMTPPass, conf.SMTPHost)
+	return SendEmail([]string{toEmail}, "Reset Password - Internship Hub", body)
+}
 
-	err := smtp.SendMail(conf.SMTPHost+":"+conf.SMTPPort, auth, conf.SMTPSender, []string{toEmail}, msg)
-	if err != nil {
-		log.Printf("Failed to send email: %v", err)
-		return err
-	}
+func SendVerificationEmail(toEmail, name, token string) error {
+	body := fmt.Sprintf(`
+		<h3>Verifikasi Email</h3>
+		<p>Halo %s,</p>
+		<p>Terima kasih telah mendaftar di Internship Hub. Silakan klik link di bawah ini untuk memverifikasi email Anda:</p>
+		<a href="http://localhost:5173/verify-email?token=%s">Verifikasi Email</a>
+		<p>Link ini berlaku selama 24 jam. Jika Anda tidak merasa mendaftar, abaikan email ini.</p>
+	`, html.EscapeString(name), token)
 
-	return nil
+	return SendEmail([]string{toEmail}, "Verifikasi Email - Internship Hub", body)
+}
+
+func SendAccountLockedEmail(toEmail, name string, until time.Time) error {
+	body := fmt.Sprintf(`
+		<h3>Akun Dikunci Sementara</h3>
+		<p>Halo %s,</p>
+		<p>Terdapat beberapa kali percobaan login yang gagal pada akun Anda, sehingga akun dikunci sementara hingga <b>%s</b>.</p>
+		<p>Jika ini bukan Anda, segera reset password Anda setelah kunci berakhir atau hubungi administrator.</p>
+	`, html.EscapeString(name), until.Format("02 January 2006 15:04 MST"))
+
+	return SendEmail([]string{toEmail}, "Akun Dikunci Sementara - Internship Hub", body)
+}
+
+func SendOfferReleasedEmail(to []string, applicantName, vacancyTitle, reason string) error {
+	body := fmt.Sprintf(`
+		<h3>Penawaran Magang Dilepas</h3>
+		<p>Penawaran magang untuk <b>%s</b> pada lowongan <b>%s</b> tidak lagi berlaku: %s.</p>
+		<p>Kuota yang sebelumnya ditahan untuk pelamar ini telah dibuka kembali.</p>
+	`, html.EscapeString(applicantName), html.EscapeString(vacancyTitle), html.EscapeString(reason))
+
+	return SendEmail(to, "Penawaran Magang Dilepas - Internship Hub", body)
+}
+
+func SendInterviewBookedEmail(toEmail, applicantName, vacancyTitle string, start time.Time, location string, invite []byte) error {
+	body := fmt.Sprintf(`
+		<h3>Jadwal Wawancara Magang</h3>
+		<p>Halo %s,</p>
+		<p>Wawancara Anda untuk lowongan <b>%s</b> dijadwalkan pada <b>%s</b> di <b>%s</b>.</p>
+		<p>Undangan kalender terlampir. Anda dapat mengubah jadwal melalui halaman lamaran Anda.</p>
+	`, html.EscapeString(applicantName), html.EscapeString(vacancyTitle), start.Format("02 January 2006 15:04 MST"), html.EscapeString(location))
+
+	return SendEmail([]string{toEmail}, "Jadwal Wawancara Magang - Internship Hub", body, Attachment{
+		FileName:    "interview.ics",
+		ContentType: "text/calendar; charset=\"UTF-8\"; method=REQUEST",
+		Data:        invite,
+	})
+}
+
+func SendWaitlistPromotedEmail(toEmail, applicantName, vacancyTitle string, expiresAt time.Time) error {
+	body := fmt.Sprintf(`
+		<h3>Penawaran Magang</h3>
+		<p>Halo %s,</p>
+		<p>Kuota lowongan <b>%s</b> kembali tersedia dan Anda, yang berada di daftar tunggu, mendapatkan penawaran magang.</p>
+		<p>Silakan terima atau tolak penawaran ini melalui halaman lamaran Anda sebelum <b>%s</b>.</p>
+	`, html.EscapeString(applicantName), html.EscapeString(vacancyTitle), expiresAt.Format("02 January 2006 15:04 MST"))
+
+	return SendEmail([]string{toEmail}, "Penawaran Magang - Internship Hub", body)
+}
+
+func SendSupervisorInvitationEmail(toEmail, supervisorName, studentName, vacancyTitle, unitName, token string, expiresAt time.Time) error {
+	body := fmt.Sprintf(`
+		<h3>Undangan Dosen Pembimbing</h3>
+		<p>Yth. %s,</p>
+		<p>Anda diundang sebagai dosen pembimbing untuk memantau magang <b>%s</b> pada lowongan <b>%s</b> di <b>%s</b>.</p>
+		<p>Melalui akun dosen pembimbing Anda dapat melihat presensi, logbook, nilai akhir dan surat keterangan selesai magang mahasiswa tersebut.</p>
+		<a href="http://localhost:5173/supervisor-invitation?token=%s">Terima Undangan</a>
+		<p>Undangan ini berlaku hingga <b>%s</b>. Jika Anda tidak mengenal mahasiswa ini, abaikan email ini.</p>
+	`, html.EscapeString(supervisorName), html.EscapeString(studentName), html.EscapeString(vacancyTitle), html.EscapeString(unitName), token, expiresAt.Format("02 January 2006 15:04 MST"))
+
+	return SendEmail([]string{toEmail}, "Undangan Dosen Pembimbing - Internship Hub", body)
 }
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CalendarEvent holds the fields written to an iCalendar (.ics) invitation
+type CalendarEvent struct {
+	UID         string
+	Sequence    int
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Organizer   string
+	Attendee    string
+}
+
+const icsTimeFormat = "20060102T150405Z"
+
+// BuildICS renders the event as an iCalendar REQUEST that calendar clients
+// can import. A higher Sequence replaces an earlier invitation with the same UID.
+func BuildICS(event CalendarEvent) []byte {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Internship Hub//Interview//ID",
+		"CALSCALE:GREGORIAN",
+		"METHOD:REQUEST",
+		"BEGIN:VEVENT",
+		"UID:" + escapeICS(event.UID),
+		fmt.Sprintf("SEQUENCE:%d", event.Sequence),
+		"DTSTAMP:" + time.Now().UTC().Format(icsTimeFormat),
+		"DTSTART:" + event.Start.UTC().Format(icsTimeFormat),
+		"DTEND:" + event.End.UTC().Format(icsTimeFormat),
+		"SUMMARY:" + escapeICS(event.Summary),
+		"DESCRIPTION:" + escapeICS(event.Description),
+		"LOCATION:" + escapeICS(event.Location),
+	}
+	if event.Organizer != "" {
+		lines = append(lines, "ORGANIZER:mailto:"+event.Organizer)
+	}
+	if event.Attendee != "" {
+		lines = append(lines, "ATTENDEE;ROLE=REQ-PARTICIPANT;RSVP=FALSE:mailto:"+event.Attendee)
+	}
+	lines = append(lines, "STATUS:CONFIRMED", "END:VEVENT", "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldICS(line))
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
+
+func escapeICS(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// foldICS splits lines longer than 75 octets as required by RFC 5545
+func foldICS(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
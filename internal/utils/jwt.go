@@ -1,10 +1,16 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"os"
+	"strconv"
 	"time"
 
+	"github.com/dr15/internship-hub-api/config"
 	"github.com/dr15/internship-hub-api/internal/models"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -17,18 +23,41 @@ type Claims struct {
 	Email       string          `json:"email"`
 	Role        models.UserRole `json:"role"`
 	UnitKerjaID *uuid.UUID      `json:"unitKerjaId,omitempty"`
+	// SessionID is the refresh token family the access token was issued for
+	SessionID uuid.UUID `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(user models.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// AccessTokenTTL is how long an access token stays valid
+func AccessTokenTTL() time.Duration {
+	minutes, err := strconv.Atoi(config.AppConfig.AccessTokenTTLMinutes)
+	if err != nil || minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// RefreshTokenTTL is how long a refresh token can be used
+func RefreshTokenTTL() time.Duration {
+	days, err := strconv.Atoi(config.AppConfig.RefreshTokenTTLDays)
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GenerateToken issues a short-lived access token for the user's session
+func GenerateToken(user models.User, sessionID uuid.UUID) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		UserID:      user.ID,
 		Email:       user.Email,
 		Role:        user.Role,
 		UnitKerjaID: user.UnitKerjaID,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
 		},
 	}
 
@@ -57,3 +86,61 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// NewRefreshToken returns a random refresh token and the hash to store for it
+func NewRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the stored form of a refresh token
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChallengeClaims identify a user who passed the password step of a login
+// that still needs a second factor
+type ChallengeClaims struct {
+	UserID uuid.UUID `json:"userId"`
+	// Enroll is set when the user must first set up two-factor authentication
+	Enroll bool `json:"enroll,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// challengeKey keeps challenge tokens from being accepted as access tokens
+func challengeKey() []byte {
+	return []byte(os.Getenv("JWT_SECRET") + ":challenge")
+}
+
+// GenerateChallengeToken issues a five minute token for the second login step
+func GenerateChallengeToken(user models.User, enroll bool) (string, error) {
+	now := time.Now()
+	claims := &ChallengeClaims{
+		UserID: user.ID,
+		Enroll: enroll,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(challengeKey())
+}
+
+func ValidateChallengeToken(tokenString string) (*ChallengeClaims, error) {
+	claims := &ChallengeClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return challengeKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpIssuer = "Internship Hub"
+	totpPeriod = 30
+	// RecoveryCodeCount is how many recovery codes a user gets at a time
+	RecoveryCodeCount = 10
+)
+
+var totpOpts = totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// TOTPSetup is what an authenticator app needs to enroll
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauthUrl"`
+	// QRCode is a PNG data URI of the otpauth URL
+	QRCode string `json:"qrCode"`
+}
+
+// NewTOTPSetup generates a new TOTP secret for the account along with its
+// provisioning URL and QR code
+func NewTOTPSetup(accountName string) (TOTPSetup, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: accountName,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return TOTPSetup{}, err
+	}
+
+	return TOTPSetup{
+		Secret: key.Secret(),
+		URL:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// MatchTOTP checks a code against the secret, allowing one period of clock
+// drift either way, and returns the time step it belongs to. Callers reject
+// steps at or below the last one used so a code cannot be replayed.
+func MatchTOTP(code, secret string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	for _, offset := range []int64{0, -1, 1} {
+		t := now.Add(time.Duration(offset*totpPeriod) * time.Second)
+		ok, err := totp.ValidateCustom(code, secret, t, totpOpts)
+		if err == nil && ok {
+			return t.Unix() / totpPeriod, true
+		}
+	}
+	return 0, false
+}
+
+// NewRecoveryCodes returns a set of recovery codes and the hashes to store
+func NewRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var b strings.Builder
+		for j, v := range buf {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(alphabet[int(v)%len(alphabet)])
+		}
+		codes[i] = b.String()
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode normalises a recovery code as typed by the user and hashes it
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
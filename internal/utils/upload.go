@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	MIMEPDF  = "application/pdf"
+	MIMEDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MIMEPNG  = "image/png"
+	MIMEJPEG = "image/jpeg"
+)
+
+var mimeNames = map[string]string{
+	MIMEPDF:  "PDF",
+	MIMEDOCX: "DOCX",
+	MIMEPNG:  "PNG",
+	MIMEJPEG: "JPEG",
+}
+
+var mimeExtensions = map[string]string{
+	MIMEPDF:  ".pdf",
+	MIMEDOCX: ".docx",
+	MIMEPNG:  ".png",
+	MIMEJPEG: ".jpg",
+}
+
+// UploadPolicy limits the size and content types accepted for an upload
+type UploadPolicy struct {
+	MaxSize int64
+	Allowed []string
+}
+
+var (
+	// DocumentPolicy is for written documents such as CVs
+	DocumentPolicy = UploadPolicy{MaxSize: 5 << 20, Allowed: []string{MIMEPDF, MIMEDOCX}}
+	// ReportPolicy is for final internship reports, which tend to be larger
+	ReportPolicy = UploadPolicy{MaxSize: 10 << 20, Allowed: []string{MIMEPDF, MIMEDOCX}}
+	// ScanPolicy is for scanned papers such as transcripts and ID cards
+	ScanPolicy = UploadPolicy{MaxSize: 5 << 20, Allowed: []string{MIMEPDF, MIMEPNG, MIMEJPEG}}
+	// AttachmentPolicy is for files given as answers to vacancy questions
+	AttachmentPolicy = UploadPolicy{MaxSize: 5 << 20, Allowed: []string{MIMEPDF, MIMEDOCX, MIMEPNG, MIMEJPEG}}
+)
+
+// UploadError explains why an uploaded file was refused. Status is the HTTP
+// status to answer with.
+type UploadError struct {
+	Status  int
+	Message string
+}
+
+func (e *UploadError) Error() string {
+	return e.Message
+}
+
+// Upload is a validated file ready to be stored
+type Upload struct {
+	Data         []byte
+	ContentType  string
+	Extension    string
+	Hash         string
+	OriginalName string
+}
+
+// Size returns the size of the file in bytes
+func (u *Upload) Size() int64 {
+	return int64(len(u.Data))
+}
+
+// ReadUpload reads an uploaded file and checks it against the policy. The
+// type is sniffed from the content, never taken from the file name, and PDF,
+// DOCX and image files must also be structurally sound.
+func ReadUpload(file *multipart.FileHeader, policy UploadPolicy) (*Upload, error) {
+	if file.Size > policy.MaxSize {
+		return nil, tooLarge(file.Size, policy.MaxSize)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// Read one byte past the limit so a lying header cannot sneak a bigger file in
+	data, err := io.ReadAll(io.LimitReader(src, policy.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > policy.MaxSize {
+		return nil, tooLarge(int64(len(data)), policy.MaxSize)
+	}
+	if len(data) == 0 {
+		return nil, &UploadError{Status: http.StatusBadRequest, Message: "file is empty"}
+	}
+
+	contentType := sniffContentType(data)
+	if !allowed(policy, contentType) {
+		names := make([]string, 0, len(policy.Allowed))
+		for _, t := range policy.Allowed {
+			names = append(names, mimeNames[t])
+		}
+		detected := contentType
+		if name, ok := mimeNames[contentType]; ok {
+			detected = name
+		}
+		return nil, &UploadError{
+			Status:  http.StatusUnsupportedMediaType,
+			Message: fmt.Sprintf("file content is %s, only %s files are accepted", detected, strings.Join(names, ", ")),
+		}
+	}
+
+	if err := checkStructure(contentType, data); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &Upload{
+		Data:         data,
+		ContentType:  contentType,
+		Extension:    mimeExtensions[contentType],
+		Hash:         hex.EncodeToString(sum[:]),
+		OriginalName: file.Filename,
+	}, nil
+}
+
+// sniffContentType detects the type from the file content. DOCX files are
+// ZIP archives, so they are told apart by their Word document part.
+func sniffContentType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	if contentType == "application/zip" && isDOCX(data) {
+		return MIMEDOCX
+	}
+	return contentType
+}
+
+func isDOCX(data []byte) bool {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return false
+	}
+	var contentTypes, document bool
+	for _, f := range archive.File {
+		switch f.Name {
+		case "[Content_Types].xml":
+			contentTypes = true
+		case "word/document.xml":
+			document = true
+		}
+	}
+	return contentTypes && document
+}
+
+func checkStructure(contentType string, data []byte) error {
+	damaged := func(kind string) error {
+		return &UploadError{Status: http.StatusBadRequest, Message: fmt.Sprintf("%s file is damaged or incomplete", kind)}
+	}
+
+	switch contentType {
+	case MIMEPDF:
+		// A complete PDF has a cross-reference pointer and ends with an EOF marker
+		tail := data
+		if len(tail) > 2048 {
+			tail = tail[len(tail)-2048:]
+		}
+		if !bytes.HasPrefix(data, []byte("%PDF-1.")) && !bytes.HasPrefix(data, []byte("%PDF-2.")) {
+			return damaged("PDF")
+		}
+		if !bytes.Contains(tail, []byte("%%EOF")) || !bytes.Contains(tail, []byte("startxref")) {
+			return damaged("PDF")
+		}
+	case MIMEDOCX:
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return damaged("DOCX")
+		}
+		for _, f := range archive.File {
+			if f.Name != "word/document.xml" {
+				continue
+			}
+			part, err := f.Open()
+			if err != nil {
+				return damaged("DOCX")
+			}
+			_, err = io.Copy(io.Discard, io.LimitReader(part, 50<<20))
+			part.Close()
+			if err != nil {
+				return damaged("DOCX")
+			}
+		}
+	case MIMEPNG, MIMEJPEG:
+		if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+			return damaged(mimeNames[contentType])
+		}
+	}
+	return nil
+}
+
+func allowed(policy UploadPolicy, contentType string) bool {
+	for _, t := range policy.Allowed {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func tooLarge(size, limit int64) error {
+	return &UploadError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: fmt.Sprintf("file is %.1f MB, the limit is %d MB", float64(size)/(1<<20), limit>>20),
+	}
+}